@@ -0,0 +1,174 @@
+package smugmug
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// latencyFactor is the increase over the best observed latency considered to be queueing
+	latencyFactor = 2.0
+	// throughputDrop is the fraction of the previous throughput below which concurrency is reduced
+	throughputDrop = 0.9
+)
+
+// WithAdaptiveConcurrency configures `Uploads` to adjust the number of concurrent uploads between
+// `minimum` and `maximum` based on the observed throughput, latency and rate of 429 and 5xx responses
+// The number of concurrent uploads starts at the value of `WithConcurrency` bounded by `minimum` and `maximum`
+func WithAdaptiveConcurrency(minimum, maximum int) Option {
+	return func(c *Client) error {
+		if minimum < 1 {
+			return errors.New("minimum concurrency must be positive")
+		}
+		if maximum < minimum {
+			return errors.New("maximum concurrency must not be less than minimum concurrency")
+		}
+		c.minConcurrency = minimum
+		c.maxConcurrency = maximum
+		return nil
+	}
+}
+
+// slot is held by an upload goroutine while it is allowed to upload
+type slot struct {
+	epoch int
+}
+
+// adaptive limits the number of concurrent uploads, increasing the limit additively while the
+// throughput improves and decreasing it when the uploads are congested
+type adaptive struct {
+	mu       sync.Mutex
+	changed  chan struct{}
+	minimum  int
+	maximum  int
+	limit    int
+	active   int
+	epoch    int
+	start    time.Time
+	count    int
+	bytes    int64
+	latency  float64
+	previous float64
+	baseline float64
+}
+
+func newAdaptive(minimum, maximum, initial int) *adaptive {
+	if maximum == 0 {
+		return nil
+	}
+	return &adaptive{
+		changed: make(chan struct{}),
+		minimum: minimum,
+		maximum: maximum,
+		limit:   min(max(initial, minimum), maximum),
+		start:   time.Now(),
+	}
+}
+
+// acquire blocks until the number of active uploads is below the limit
+func (a *adaptive) acquire(ctx context.Context) (slot, error) {
+	if a == nil {
+		return slot{}, nil
+	}
+	for {
+		a.mu.Lock()
+		if a.active < a.limit {
+			a.active++
+			sl := slot{epoch: a.epoch}
+			a.mu.Unlock()
+			return sl, nil
+		}
+		changed := a.changed
+		a.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return slot{}, ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// cancel releases the slot without recording an upload
+func (a *adaptive) cancel() {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.active--
+	a.notify()
+}
+
+// release releases the slot and adjusts the limit using the outcome of the upload
+func (a *adaptive) release(sl slot, size int64, elapsed time.Duration, err error) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.active--
+	defer a.notify()
+
+	if err != nil {
+		// only the first congested upload started at the current limit reduces it
+		if congested(err) && sl.epoch == a.epoch {
+			a.adjust(a.limit / 2) //nolint:mnd // multiplicative decrease
+		}
+		return
+	}
+
+	a.count++
+	a.bytes += size
+	a.latency += elapsed.Seconds() / float64(max(size, 1))
+	if a.count < a.limit {
+		return
+	}
+
+	throughput := float64(a.bytes) / time.Since(a.start).Seconds()
+	latency := a.latency / float64(a.count)
+	if a.baseline == 0 || latency < a.baseline {
+		a.baseline = latency
+	}
+	previous := a.previous
+	a.previous = throughput
+
+	switch {
+	case latency > latencyFactor*a.baseline:
+		a.adjust(a.limit - 1)
+	case previous > 0 && throughput < throughputDrop*previous:
+		a.adjust(a.limit - 1)
+	default:
+		a.adjust(a.limit + 1)
+	}
+}
+
+// adjust sets the limit bounded by the minimum and maximum and starts a new sample
+func (a *adaptive) adjust(limit int) {
+	limit = min(max(limit, a.minimum), a.maximum)
+	if limit < a.limit {
+		a.epoch++
+	}
+	a.limit = limit
+	a.start = time.Now()
+	a.count = 0
+	a.bytes = 0
+	a.latency = 0
+}
+
+// notify wakes the goroutines waiting to acquire a slot
+func (a *adaptive) notify() {
+	close(a.changed)
+	a.changed = make(chan struct{})
+}
+
+// congested returns true if SmugMug responded with too many requests or a server error
+func congested(err error) bool {
+	var fault *Fault
+	if errors.As(err, &fault) {
+		return fault.Code == http.StatusTooManyRequests || fault.Code >= http.StatusInternalServerError
+	}
+	return false
+}
@@ -0,0 +1,132 @@
+package smugmug_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bzimmer/smugmug"
+)
+
+// concurrent records the number of requests in flight when each request arrives
+type concurrent struct {
+	mu       sync.Mutex
+	active   int
+	observed []int
+}
+
+func (c *concurrent) handler(status int, delay time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c.mu.Lock()
+		c.active++
+		c.observed = append(c.observed, c.active)
+		c.mu.Unlock()
+		defer func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			c.active--
+		}()
+		time.Sleep(delay)
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+		http.ServeFile(w, r, "testdata/upload_CVvj69L.json")
+	}
+}
+
+func (c *concurrent) peak() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return slices.Max(c.observed)
+}
+
+func uploadables(n int) sliceUploadables {
+	ups := make(sliceUploadables, n)
+	for i := range n {
+		ups[i] = &smugmug.Uploadable{Name: fmt.Sprintf("DSC%04d.jpg", i), AlbumKey: "7dFHSm"}
+	}
+	return ups
+}
+
+func TestWithAdaptiveConcurrency(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	mg, err := smugmug.NewClient(smugmug.WithAdaptiveConcurrency(0, 4))
+	a.Error(err)
+	a.Nil(mg)
+
+	mg, err = smugmug.NewClient(smugmug.WithAdaptiveConcurrency(4, 2))
+	a.Error(err)
+	a.Nil(mg)
+
+	mg, err = smugmug.NewClient(smugmug.WithAdaptiveConcurrency(1, 4))
+	a.NoError(err)
+	a.NotNil(mg)
+}
+
+func TestAdaptiveConcurrencyIncrease(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	c := &concurrent{}
+	svr := httptest.NewServer(c.handler(http.StatusOK, 20*time.Millisecond))
+	defer svr.Close()
+
+	mg, err := smugmug.NewClient(
+		smugmug.WithUploadURL(svr.URL),
+		smugmug.WithConcurrency(1),
+		smugmug.WithAdaptiveConcurrency(1, 4))
+	a.NoError(err)
+
+	summary, err := smugmug.Summarize(mg.Upload.Uploads(context.TODO(), uploadables(30)))
+	a.NoError(err)
+	a.Len(summary.Succeeded, 30)
+	a.Greater(c.peak(), 1)
+	a.LessOrEqual(c.peak(), 4)
+}
+
+func TestAdaptiveConcurrencyDecrease(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		status int
+	}{
+		{name: "too many requests", status: http.StatusTooManyRequests},
+		{name: "server error", status: http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			c := &concurrent{}
+			svr := httptest.NewServer(c.handler(tt.status, 10*time.Millisecond))
+			defer svr.Close()
+
+			mg, err := smugmug.NewClient(
+				smugmug.WithUploadURL(svr.URL),
+				smugmug.WithConcurrency(4),
+				smugmug.WithContinueOnError(true),
+				smugmug.WithAdaptiveConcurrency(1, 4))
+			a.NoError(err)
+
+			summary, err := smugmug.Summarize(mg.Upload.Uploads(context.TODO(), uploadables(12)))
+			a.NoError(err)
+			a.Len(summary.Failed, 12)
+			a.Equal(4, c.peak())
+			// once congested the uploads are sent one at a time
+			a.Equal([]int{1, 1, 1}, c.observed[len(c.observed)-3:])
+		})
+	}
+}
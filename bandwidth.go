@@ -0,0 +1,146 @@
+package smugmug
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// BandwidthWindow applies a limit during a period of the day
+type BandwidthWindow struct {
+	// Start is the offset from midnight (local time) at which the window begins
+	Start time.Duration `json:"Start"`
+	// End is the offset from midnight (local time) at which the window ends
+	// If End is before Start the window spans midnight
+	End time.Duration `json:"End"`
+	// Limit is the number of bytes per second allowed during the window, zero is unlimited
+	Limit int `json:"Limit"`
+}
+
+// contains returns true if the window includes the time of day `offset`
+func (w *BandwidthWindow) contains(offset time.Duration) bool {
+	if w.Start <= w.End {
+		return w.Start <= offset && offset < w.End
+	}
+	return offset >= w.Start || offset < w.End
+}
+
+// Bandwidth limits the bytes per second transferred by all readers sharing it
+type Bandwidth struct {
+	mu       sync.Mutex
+	limit    int
+	windows  []BandwidthWindow
+	limiter  *rate.Limiter
+	previous int
+}
+
+// NewBandwidth returns a Bandwidth limited to `limit` bytes per second, zero is unlimited
+// The first window containing the current time of day overrides the limit
+func NewBandwidth(limit int, windows ...BandwidthWindow) *Bandwidth {
+	b := &Bandwidth{limit: limit, windows: windows, limiter: rate.NewLimiter(rate.Inf, 0)}
+	b.apply(b.LimitAt(time.Now()))
+	return b
+}
+
+// WithBandwidth limits the bytes per second of upload bodies and download streams
+func WithBandwidth(bandwidth *Bandwidth) Option {
+	return func(c *Client) error {
+		c.bandwidth = bandwidth
+		return nil
+	}
+}
+
+// SetLimit changes the limit to `limit` bytes per second, zero is unlimited
+func (b *Bandwidth) SetLimit(limit int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.limit = limit
+}
+
+// LimitAt returns the limit in bytes per second in effect at time `t`
+func (b *Bandwidth) LimitAt(t time.Time) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	offset := t.Sub(midnight)
+	for i := range b.windows {
+		if b.windows[i].contains(offset) {
+			return b.windows[i].Limit
+		}
+	}
+	return b.limit
+}
+
+// apply updates the limiter if the limit changed and returns the largest number of bytes to wait for at once
+func (b *Bandwidth) apply(limit int) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if limit != b.previous {
+		b.previous = limit
+		switch {
+		case limit <= 0:
+			b.limiter.SetLimit(rate.Inf)
+			b.limiter.SetBurst(0)
+		default:
+			b.limiter.SetLimit(rate.Limit(limit))
+			b.limiter.SetBurst(limit)
+		}
+	}
+	return limit
+}
+
+// wait blocks until `n` bytes are allowed, in increments no larger than the burst of the limiter
+func (b *Bandwidth) wait(ctx context.Context, n int) error {
+	for n > 0 {
+		if b.limiter.Limit() == rate.Inf {
+			return nil
+		}
+		m := min(n, max(b.limiter.Burst(), 1))
+		if err := b.limiter.WaitN(ctx, m); err != nil {
+			return err
+		}
+		n -= m
+	}
+	return nil
+}
+
+// Reader returns a reader limited by the bandwidth
+func (b *Bandwidth) Reader(ctx context.Context, r io.Reader) io.Reader {
+	if b == nil || r == nil {
+		return r
+	}
+	return &bandwidthReader{ctx: ctx, r: r, bandwidth: b}
+}
+
+// bandwidthReader waits for the bandwidth to allow the bytes read
+type bandwidthReader struct {
+	ctx       context.Context //nolint:containedctx // the context of the request being read
+	r         io.Reader
+	bandwidth *Bandwidth
+}
+
+// Read reads no more bytes than the limit allows per second
+func (t *bandwidthReader) Read(p []byte) (int, error) {
+	limit := t.bandwidth.apply(t.bandwidth.LimitAt(time.Now()))
+	if limit > 0 && len(p) > limit {
+		p = p[:limit]
+	}
+	n, err := t.r.Read(p)
+	if n > 0 && limit > 0 {
+		if werr := t.bandwidth.wait(t.ctx, n); werr != nil {
+			return n, werr
+		}
+	}
+	return n, err
+}
+
+// Close closes the underlying reader if it is an io.Closer
+func (t *bandwidthReader) Close() error {
+	if c, ok := t.r.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
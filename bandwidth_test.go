@@ -0,0 +1,97 @@
+package smugmug_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bzimmer/smugmug"
+)
+
+func TestBandwidthLimitAt(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	b := smugmug.NewBandwidth(1000,
+		smugmug.BandwidthWindow{Start: 22 * time.Hour, End: 6 * time.Hour, Limit: 0},
+		smugmug.BandwidthWindow{Start: 12 * time.Hour, End: 13 * time.Hour, Limit: 5000},
+	)
+
+	day := time.Date(2024, time.March, 4, 0, 0, 0, 0, time.Local)
+	a.Equal(0, b.LimitAt(day.Add(23*time.Hour)))
+	a.Equal(0, b.LimitAt(day.Add(2*time.Hour)))
+	a.Equal(1000, b.LimitAt(day.Add(6*time.Hour)))
+	a.Equal(5000, b.LimitAt(day.Add(12*time.Hour+30*time.Minute)))
+	a.Equal(1000, b.LimitAt(day.Add(15*time.Hour)))
+
+	b.SetLimit(2000)
+	a.Equal(2000, b.LimitAt(day.Add(15*time.Hour)))
+	a.Equal(0, b.LimitAt(day.Add(23*time.Hour)))
+}
+
+func TestBandwidthReader(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	var b *smugmug.Bandwidth
+	r := strings.NewReader("this is a test")
+	a.Equal(r, b.Reader(context.TODO(), r))
+
+	b = smugmug.NewBandwidth(0)
+	n, err := io.Copy(io.Discard, b.Reader(context.TODO(), strings.NewReader(strings.Repeat("a", 100000))))
+	a.NoError(err)
+	a.Equal(int64(100000), n)
+
+	// the first 10000 bytes are immediately available, the remaining take half a second
+	b.SetLimit(10000)
+	t0 := time.Now()
+	n, err = io.Copy(io.Discard, b.Reader(context.TODO(), strings.NewReader(strings.Repeat("a", 15000))))
+	a.NoError(err)
+	a.Equal(int64(15000), n)
+	a.GreaterOrEqual(time.Since(t0), 400*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	_, err = io.Copy(io.Discard, b.Reader(ctx, strings.NewReader(strings.Repeat("a", 15000))))
+	a.ErrorIs(err, context.Canceled)
+}
+
+func TestBandwidthClient(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", func(w http.ResponseWriter, r *http.Request) {
+		b, err := io.ReadAll(r.Body)
+		a.NoError(err)
+		a.Len(b, 3000)
+		http.ServeFile(w, r, "testdata/upload_CVvj69L.json")
+	})
+	mux.HandleFunc("/!authuser", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "testdata/user_cmac.json")
+	})
+	svr := httptest.NewServer(mux)
+	defer svr.Close()
+
+	mg, err := smugmug.NewClient(
+		smugmug.WithBaseURL(svr.URL),
+		smugmug.WithUploadURL(svr.URL),
+		smugmug.WithBandwidth(smugmug.NewBandwidth(100000)))
+	a.NoError(err)
+
+	up := &smugmug.Uploadable{
+		Name: "DSC0001.jpg", AlbumKey: "7dFHSm", Size: 3000, Reader: strings.NewReader(strings.Repeat("a", 3000))}
+	upload, err := mg.Upload.Upload(context.TODO(), up)
+	a.NoError(err)
+	a.NotNil(upload)
+
+	user, err := mg.User.AuthUser(context.TODO())
+	a.NoError(err)
+	a.NotNil(user)
+}
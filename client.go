@@ -81,7 +81,7 @@ func (c *Client) do(req *http.Request, v any) error {
 	if res.StatusCode >= http.StatusBadRequest {
 		return c.decodeError(res)
 	}
-	return c.decode(res.Body, v)
+	return c.decode(c.bandwidth.Reader(ctx, res.Body), v)
 }
 
 // decodeError decodes an HTTP error response into a Fault.
@@ -0,0 +1,158 @@
+package smugmug
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// JournalEntry records a completed upload
+type JournalEntry struct {
+	// Path is the location of the uploaded file
+	Path string `json:"Path"`
+	// MD5 is the hash of the file contents
+	MD5 string `json:"MD5"`
+	// Size is the size in bytes uploaded
+	Size int64 `json:"Size"`
+	// SourceSize is the size in bytes of the file, which differs from Size if the contents were rewritten
+	SourceSize int64 `json:"SourceSize"`
+	// ModTime is the modification time of the file
+	ModTime time.Time `json:"ModTime"`
+	// AlbumKey is the album into which the file was uploaded
+	AlbumKey string `json:"AlbumKey"`
+	// ImageURI is the uri of the uploaded image
+	ImageURI string `json:"ImageUri"`
+}
+
+// Journal is an append-only record of completed uploads used to resume an interrupted batch
+// A journal should record uploads for a single destination
+type Journal struct {
+	mu      sync.Mutex
+	w       io.Writer
+	entries map[string]*JournalEntry
+}
+
+// OpenJournal opens, or creates if necessary, the journal file `filename`
+func OpenJournal(filename string) (*Journal, error) {
+	fp, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	j, err := NewJournal(fp)
+	if err != nil {
+		_ = fp.Close()
+		return nil, err
+	}
+	return j, nil
+}
+
+// NewJournal reads the existing entries from `rw` and appends new entries to it
+func NewJournal(rw io.ReadWriter) (*Journal, error) {
+	data, err := io.ReadAll(rw)
+	if err != nil {
+		return nil, err
+	}
+	j := &Journal{w: rw, entries: make(map[string]*JournalEntry)}
+	for line := range bytes.Lines(data) {
+		entry := &JournalEntry{}
+		if err = json.Unmarshal(line, entry); err != nil {
+			// an entry may be incomplete if the process was killed while writing
+			continue
+		}
+		j.entries[entry.Path] = entry
+	}
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		// terminate a partially written entry so the next entry starts on its own line
+		if _, err = rw.Write([]byte("\n")); err != nil {
+			return nil, err
+		}
+	}
+	return j, nil
+}
+
+// Lookup returns the entry for the file at `path` if it has not changed since being uploaded
+// The `size` is the size of the file, which is compared with the SourceSize of the entry
+func (j *Journal) Lookup(path string, size int64, modTime time.Time) (*JournalEntry, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	entry, ok := j.entries[path]
+	if !ok || entry.sourceSize() != size || !entry.ModTime.Equal(modTime) {
+		return nil, false
+	}
+	return entry, true
+}
+
+// Confirmed returns the entry for the Uploadable if it has not changed since being uploaded to the same album
+func (j *Journal) Confirmed(up *Uploadable) (*JournalEntry, bool) {
+	if up.Path == "" {
+		return nil, false
+	}
+	entry, ok := j.Lookup(up.Path, sourceSize(up), up.ModTime)
+	if !ok || (up.MD5 != "" && up.MD5 != entry.MD5) || up.AlbumKey != entry.AlbumKey {
+		return nil, false
+	}
+	return entry, true
+}
+
+// sourceSize returns the size of the entry's file, using Size for entries recorded before SourceSize
+func (e *JournalEntry) sourceSize() int64 {
+	if e.SourceSize > 0 {
+		return e.SourceSize
+	}
+	return e.Size
+}
+
+// sourceSize returns the size of the Uploadable's source file, using Size if the source size is not known
+func sourceSize(up *Uploadable) int64 {
+	if up.SourceSize > 0 {
+		return up.SourceSize
+	}
+	return up.Size
+}
+
+// Record appends the completed upload to the journal
+// Uploads of Uploadables without a Path are not recorded
+func (j *Journal) Record(upload *Upload) error {
+	up := upload.Uploadable
+	if up == nil || up.Path == "" {
+		return nil
+	}
+	entry := &JournalEntry{
+		Path:       up.Path,
+		MD5:        up.MD5,
+		Size:       up.Size,
+		SourceSize: sourceSize(up),
+		ModTime:    up.ModTime,
+		AlbumKey:   up.AlbumKey,
+		ImageURI:   upload.ImageURI,
+	}
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if _, err = j.w.Write(append(b, '\n')); err != nil {
+		return err
+	}
+	if s, ok := j.w.(interface{ Sync() error }); ok {
+		if err = s.Sync(); err != nil {
+			return err
+		}
+	}
+	j.entries[entry.Path] = entry
+	return nil
+}
+
+// Close closes the underlying journal if it is an io.Closer
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if c, ok := j.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
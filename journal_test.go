@@ -0,0 +1,179 @@
+package smugmug_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bzimmer/smugmug"
+)
+
+func TestJournal(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	modTime := time.Date(2024, time.March, 4, 10, 11, 12, 0, time.UTC)
+	var buf bytes.Buffer
+	j, err := smugmug.NewJournal(&buf)
+	a.NoError(err)
+	a.Empty(buf.String())
+
+	up := &smugmug.Uploadable{Name: "DSC0001.jpg", Path: "photos/DSC0001.jpg", MD5: "abc", Size: 14, ModTime: modTime}
+	a.NoError(j.Record(&smugmug.Upload{Uploadable: up, ImageURI: "/api/v2/image/CVvj69L-0"}))
+	a.NoError(j.Record(&smugmug.Upload{Uploadable: &smugmug.Uploadable{Name: "DSC0002.jpg"}}))
+	a.Equal(1, strings.Count(buf.String(), "\n"))
+
+	entry, ok := j.Lookup("photos/DSC0001.jpg", 14, modTime)
+	a.True(ok)
+	a.Equal("/api/v2/image/CVvj69L-0", entry.ImageURI)
+	_, ok = j.Lookup("photos/DSC0001.jpg", 15, modTime)
+	a.False(ok)
+	_, ok = j.Lookup("photos/DSC0001.jpg", 14, modTime.Add(time.Second))
+	a.False(ok)
+
+	_, ok = j.Confirmed(up)
+	a.True(ok)
+	_, ok = j.Confirmed(&smugmug.Uploadable{Path: "photos/DSC0001.jpg", MD5: "def", Size: 14, ModTime: modTime})
+	a.False(ok)
+	_, ok = j.Confirmed(&smugmug.Uploadable{Name: "DSC0001.jpg"})
+	a.False(ok)
+	// an upload to a different album is not confirmed
+	_, ok = j.Confirmed(&smugmug.Uploadable{
+		Path: "photos/DSC0001.jpg", MD5: "abc", Size: 14, ModTime: modTime, AlbumKey: "7dFHSm"})
+	a.False(ok)
+
+	// the size of the file is recorded when its contents were rewritten before uploading
+	up = &smugmug.Uploadable{
+		Path: "photos/DSC0005.jpg", MD5: "ghi", Size: 10, SourceSize: 14, ModTime: modTime, AlbumKey: "7dFHSm"}
+	a.NoError(j.Record(&smugmug.Upload{Uploadable: up}))
+	_, ok = j.Lookup("photos/DSC0005.jpg", 14, modTime)
+	a.True(ok)
+	_, ok = j.Lookup("photos/DSC0005.jpg", 10, modTime)
+	a.False(ok)
+	_, ok = j.Confirmed(up)
+	a.True(ok)
+	a.NoError(j.Close())
+
+	up = &smugmug.Uploadable{Name: "DSC0001.jpg", Path: "photos/DSC0001.jpg", MD5: "abc", Size: 14, ModTime: modTime}
+
+	// simulate a process killed while writing an entry
+	buf.WriteString(`{"Path":"photos/DSC0003.jpg","MD5":`)
+	j, err = smugmug.NewJournal(&buf)
+	a.NoError(err)
+	_, ok = j.Confirmed(up)
+	a.True(ok)
+	up = &smugmug.Uploadable{Name: "DSC0004.jpg", Path: "photos/DSC0004.jpg", Size: 14, ModTime: modTime}
+	a.NoError(j.Record(&smugmug.Upload{Uploadable: up}))
+
+	j, err = smugmug.NewJournal(&buf)
+	a.NoError(err)
+	_, ok = j.Confirmed(up)
+	a.True(ok)
+}
+
+func TestOpenJournal(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	filename := filepath.Join(t.TempDir(), "journal.jsonl")
+	modTime := time.Date(2024, time.March, 4, 10, 11, 12, 0, time.UTC)
+	up := &smugmug.Uploadable{Name: "DSC0001.jpg", Path: "photos/DSC0001.jpg", MD5: "abc", Size: 14, ModTime: modTime}
+
+	j, err := smugmug.OpenJournal(filename)
+	a.NoError(err)
+	a.NoError(j.Record(&smugmug.Upload{Uploadable: up}))
+	a.NoError(j.Close())
+
+	j, err = smugmug.OpenJournal(filename)
+	a.NoError(err)
+	_, ok := j.Confirmed(up)
+	a.True(ok)
+	a.NoError(j.Close())
+
+	j, err = smugmug.OpenJournal(t.TempDir())
+	a.Error(err)
+	a.Nil(j)
+}
+
+func TestUploadsJournal(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	var uploads atomic.Int32
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uploads.Add(1)
+		http.ServeFile(w, r, "testdata/upload_CVvj69L.json")
+	}))
+	defer svr.Close()
+
+	var buf bytes.Buffer
+	j, err := smugmug.NewJournal(&buf)
+	a.NoError(err)
+
+	mg, err := smugmug.NewClient(smugmug.WithUploadURL(svr.URL), smugmug.WithJournal(j))
+	a.NoError(err)
+
+	modTime := time.Date(2024, time.March, 4, 10, 11, 12, 0, time.UTC)
+	uploadables := sliceUploadables{
+		{Name: "DSC0001.jpg", Path: "photos/DSC0001.jpg", AlbumKey: "7dFHSm", ModTime: modTime},
+		{Name: "DSC0002.jpg", Path: "photos/DSC0002.jpg", AlbumKey: "7dFHSm", ModTime: modTime},
+	}
+
+	summary, err := smugmug.Summarize(mg.Upload.Uploads(context.TODO(), uploadables))
+	a.NoError(err)
+	a.Len(summary.Succeeded, 2)
+	a.Equal(int32(2), uploads.Load())
+
+	// restart the batch
+	j, err = smugmug.NewJournal(bytes.NewBuffer(buf.Bytes()))
+	a.NoError(err)
+	mg, err = smugmug.NewClient(smugmug.WithUploadURL(svr.URL), smugmug.WithJournal(j))
+	a.NoError(err)
+
+	summary, err = smugmug.Summarize(mg.Upload.Uploads(context.TODO(), uploadables))
+	a.NoError(err)
+	a.Empty(summary.Succeeded)
+	a.Len(summary.Skipped, 2)
+	a.Equal(int32(2), uploads.Load())
+	for _, upload := range summary.Skipped {
+		a.ErrorIs(upload.Err, smugmug.ErrSkip)
+		a.Equal("/api/v2/image/CVvj69L-0", upload.ImageURI)
+	}
+}
+
+type failingWriter struct {
+	bytes.Buffer
+}
+
+func (f *failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestUploadJournalError(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "testdata/upload_CVvj69L.json")
+	}))
+	defer svr.Close()
+
+	j, err := smugmug.NewJournal(&failingWriter{})
+	a.NoError(err)
+	mg, err := smugmug.NewClient(smugmug.WithUploadURL(svr.URL), smugmug.WithJournal(j))
+	a.NoError(err)
+
+	upload, err := mg.Upload.Upload(context.TODO(),
+		&smugmug.Uploadable{Name: "DSC0001.jpg", Path: "photos/DSC0001.jpg", AlbumKey: "7dFHSm"})
+	a.Error(err)
+	a.Nil(upload)
+}
@@ -0,0 +1,172 @@
+package smugmug
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// MaxImageSize is the largest image SmugMug accepts
+	MaxImageSize = 150 << 20
+	// MaxVideoSize is the largest video SmugMug accepts
+	MaxVideoSize = 3 << 30
+
+	// sniffLen is the number of bytes required to detect the media type
+	sniffLen = 512
+)
+
+var (
+	// ErrUnknownMediaType is returned when the media type cannot be detected
+	ErrUnknownMediaType = errors.New("unknown media type")
+	// ErrMediaTooLarge is returned when the media exceeds the size SmugMug accepts
+	ErrMediaTooLarge = errors.New("media too large")
+)
+
+// MediaType describes a kind of media supported by SmugMug
+type MediaType struct {
+	// ContentType is the MIME type of the media
+	ContentType string `json:"ContentType"`
+	// Video is true if the media is a video
+	Video bool `json:"Video"`
+	// MaxSize is the largest size in bytes SmugMug accepts for the media
+	MaxSize int64 `json:"MaxSize"`
+}
+
+func imageType(contentType string) *MediaType {
+	return &MediaType{ContentType: contentType, MaxSize: MaxImageSize}
+}
+
+func videoType(contentType string) *MediaType {
+	return &MediaType{ContentType: contentType, Video: true, MaxSize: MaxVideoSize}
+}
+
+// tiffRaw are the raw formats stored in a TIFF container distinguishable only by extension
+func tiffRaw(ext string) string {
+	switch ext {
+	case ".nef", ".nrw":
+		return "image/x-nikon-nef"
+	case ".arw", ".srf", ".sr2":
+		return "image/x-sony-arw"
+	case ".dng":
+		return "image/x-adobe-dng"
+	case ".pef":
+		return "image/x-pentax-pef"
+	case ".srw":
+		return "image/x-samsung-srw"
+	default:
+		return "image/tiff"
+	}
+}
+
+// isobmff detects the media types based on the ISO base media file format (eg MP4, MOV, HEIC, CR3)
+func isobmff(header []byte) *MediaType {
+	if len(header) < 8 {
+		return nil
+	}
+	switch string(header[4:8]) {
+	case "ftyp":
+		if len(header) < 12 {
+			return nil
+		}
+	case "moov", "mdat", "wide", "free", "skip", "pnot":
+		// older QuickTime files do not start with a file type box
+		return videoType("video/quicktime")
+	default:
+		return nil
+	}
+	switch string(header[8:12]) {
+	case "heic", "heix", "hevc", "hevx", "heim", "heis", "mif1", "msf1":
+		return imageType("image/heic")
+	case "avif", "avis":
+		return imageType("image/avif")
+	case "crx ":
+		return imageType("image/x-canon-cr3")
+	case "qt  ":
+		return videoType("video/quicktime")
+	default:
+		return videoType("video/mp4")
+	}
+}
+
+// DetectMediaType returns the media type of the data starting with `header`
+// The filename is used only to distinguish raw formats sharing a TIFF container
+func DetectMediaType(filename string, header []byte) (*MediaType, error) {
+	ext := strings.ToLower(filepath.Ext(filename))
+	switch {
+	case bytes.HasPrefix(header, []byte("\xff\xd8\xff")):
+		return imageType("image/jpeg"), nil
+	case bytes.HasPrefix(header, []byte("\x89PNG\r\n\x1a\n")):
+		return imageType("image/png"), nil
+	case bytes.HasPrefix(header, []byte("GIF87a")), bytes.HasPrefix(header, []byte("GIF89a")):
+		return imageType("image/gif"), nil
+	case bytes.HasPrefix(header, []byte("FUJIFILMCCD-RAW")):
+		return imageType("image/x-fuji-raf"), nil
+	case bytes.HasPrefix(header, []byte("IIRO")), bytes.HasPrefix(header, []byte("IIRS")),
+		bytes.HasPrefix(header, []byte("MMOR")):
+		return imageType("image/x-olympus-orf"), nil
+	case bytes.HasPrefix(header, []byte("IIU\x00")):
+		return imageType("image/x-panasonic-rw2"), nil
+	case bytes.HasPrefix(header, []byte("II*\x00")), bytes.HasPrefix(header, []byte("MM\x00*")):
+		if len(header) >= 10 && string(header[8:10]) == "CR" {
+			return imageType("image/x-canon-cr2"), nil
+		}
+		return imageType(tiffRaw(ext)), nil
+	}
+	if mt := isobmff(header); mt != nil {
+		return mt, nil
+	}
+	return nil, ErrUnknownMediaType
+}
+
+// sniff detects the media type of the Uploadable from the first bytes of its Reader
+// The Reader must be an io.Seeker as it is repositioned at the start after reading
+func sniff(up *Uploadable) (*MediaType, error) {
+	rs, ok := up.Reader.(io.ReadSeeker)
+	if !ok {
+		return nil, ErrUnknownMediaType
+	}
+	header := make([]byte, sniffLen)
+	n, err := io.ReadFull(rs, header)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, err
+	}
+	if _, err = rs.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return DetectMediaType(up.Name, header[:n])
+}
+
+// mediaType returns the media type of the Uploadable, verifying the size is within SmugMug's limits
+func mediaType(up *Uploadable) (*MediaType, error) {
+	var mt *MediaType
+	if up.ContentType != "" {
+		mt = lookupMediaType(up.ContentType)
+	} else {
+		var err error
+		mt, err = sniff(up)
+		if err != nil {
+			if errors.Is(err, ErrUnknownMediaType) {
+				// let SmugMug decide whether the media is acceptable
+				return nil, nil //nolint:nilnil // an unknown media type is not an error
+			}
+			return nil, err
+		}
+	}
+	if mt != nil && up.Size > mt.MaxSize {
+		return nil, fmt.Errorf("%w: `%s` is %d bytes exceeding the maximum of %d bytes for %s",
+			ErrMediaTooLarge, up.Name, up.Size, mt.MaxSize, mt.ContentType)
+	}
+	return mt, nil
+}
+
+// lookupMediaType returns a MediaType for the content type with the limits of its kind
+func lookupMediaType(contentType string) *MediaType {
+	if strings.HasPrefix(contentType, "video/") {
+		return videoType(contentType)
+	}
+	return imageType(contentType)
+}
@@ -0,0 +1,144 @@
+package smugmug_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bzimmer/smugmug"
+)
+
+func TestDetectMediaType(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		filename    string
+		header      string
+		contentType string
+		video       bool
+	}{
+		{name: "jpeg", filename: "a.jpg", header: "\xff\xd8\xff\xe1", contentType: "image/jpeg"},
+		{name: "png", filename: "a.png", header: "\x89PNG\r\n\x1a\n", contentType: "image/png"},
+		{name: "gif", filename: "a.gif", header: "GIF89a", contentType: "image/gif"},
+		{name: "tiff", filename: "a.tif", header: "II*\x00\x08\x00\x00\x00", contentType: "image/tiff"},
+		{name: "cr2", filename: "a.cr2", header: "II*\x00\x10\x00\x00\x00CR\x02\x00", contentType: "image/x-canon-cr2"},
+		{name: "nef", filename: "a.NEF", header: "MM\x00*\x00\x00\x00\x08", contentType: "image/x-nikon-nef"},
+		{name: "arw", filename: "a.arw", header: "II*\x00\x08\x00\x00\x00", contentType: "image/x-sony-arw"},
+		{name: "dng", filename: "a.dng", header: "II*\x00\x08\x00\x00\x00", contentType: "image/x-adobe-dng"},
+		{name: "raf", filename: "a.raf", header: "FUJIFILMCCD-RAW 0201", contentType: "image/x-fuji-raf"},
+		{name: "orf", filename: "a.orf", header: "IIRO\x08\x00\x00\x00", contentType: "image/x-olympus-orf"},
+		{name: "rw2", filename: "a.rw2", header: "IIU\x00\x18\x00\x00\x00", contentType: "image/x-panasonic-rw2"},
+		{name: "heic", filename: "a.heic", header: "\x00\x00\x00\x18ftypheic", contentType: "image/heic"},
+		{name: "cr3", filename: "a.cr3", header: "\x00\x00\x00\x18ftypcrx ", contentType: "image/x-canon-cr3"},
+		{name: "mp4", filename: "a.mp4", header: "\x00\x00\x00\x20ftypisom", contentType: "video/mp4", video: true},
+		{name: "mov", filename: "a.mov", header: "\x00\x00\x00\x14ftypqt  ", contentType: "video/quicktime", video: true},
+		{name: "old mov", filename: "a.mov", header: "\x00\x00\x00\x08wide\x00\x00", contentType: "video/quicktime",
+			video: true},
+		{name: "unknown", filename: "a.txt", header: "this is a test"},
+		{name: "empty", filename: "a.jpg"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			mt, err := smugmug.DetectMediaType(tt.filename, []byte(tt.header))
+			if tt.contentType == "" {
+				a.ErrorIs(err, smugmug.ErrUnknownMediaType)
+				a.Nil(mt)
+				return
+			}
+			a.NoError(err)
+			a.Equal(tt.contentType, mt.ContentType)
+			a.Equal(tt.video, mt.Video)
+		})
+	}
+}
+
+func TestUploadMediaType(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		up          *smugmug.Uploadable
+		contentType string
+		err         error
+	}{
+		{
+			name: "sniffed",
+			up: &smugmug.Uploadable{Name: "DSC0001.jpg", AlbumKey: "7dFHSm", Size: 4,
+				Reader: strings.NewReader("\xff\xd8\xff\xe1")},
+			contentType: "image/jpeg",
+		},
+		{
+			name: "specified",
+			up: &smugmug.Uploadable{Name: "DSC0001.mov", AlbumKey: "7dFHSm", Size: 4,
+				ContentType: "video/quicktime", Reader: strings.NewReader("\xff\xd8\xff\xe1")},
+			contentType: "video/quicktime",
+		},
+		{
+			name: "unknown",
+			up: &smugmug.Uploadable{Name: "DSC0001.jpg", AlbumKey: "7dFHSm", Size: 14,
+				Reader: strings.NewReader("this is a test")},
+		},
+		{
+			name: "image too large",
+			up: &smugmug.Uploadable{Name: "DSC0001.jpg", AlbumKey: "7dFHSm", Size: smugmug.MaxImageSize + 1,
+				Reader: strings.NewReader("\xff\xd8\xff\xe1")},
+			err: smugmug.ErrMediaTooLarge,
+		},
+		{
+			name: "video too large",
+			up: &smugmug.Uploadable{Name: "DSC0001.mp4", AlbumKey: "7dFHSm", Size: smugmug.MaxVideoSize + 1,
+				ContentType: "video/mp4"},
+			err: smugmug.ErrMediaTooLarge,
+		},
+		{
+			name: "failed seek",
+			up: &smugmug.Uploadable{Name: "DSC0001.jpg", AlbumKey: "7dFHSm", Size: 4,
+				Reader: &badSeeker{strings.NewReader("\xff\xd8\xff\xe1")}},
+			err: errSeek,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				a.Equal(tt.contentType, r.Header.Get("Content-Type"))
+				http.ServeFile(w, r, "testdata/upload_CVvj69L.json")
+			}))
+			defer svr.Close()
+
+			mg, err := smugmug.NewClient(smugmug.WithUploadURL(svr.URL))
+			a.NoError(err)
+
+			upload, err := mg.Upload.Upload(context.TODO(), tt.up)
+			if tt.err != nil {
+				a.ErrorIs(err, tt.err)
+				a.Nil(upload)
+				return
+			}
+			a.NoError(err)
+			a.NotNil(upload)
+		})
+	}
+}
+
+var errSeek = errors.New("seek")
+
+type badSeeker struct {
+	*strings.Reader
+}
+
+func (b *badSeeker) Seek(int64, int) (int64, error) {
+	return 0, errSeek
+}
@@ -1,6 +1,7 @@
 package smugmug
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -488,16 +489,53 @@ type ImageMetadata struct {
 type Uploadable struct {
 	// Name is the basename of the image (not the full path)
 	Name string `json:"Name"`
+	// Path is the location of the source file, if any
+	Path string `json:"Path"`
+	// OriginalName is the basename of the image before it was renamed, if the name was changed
+	OriginalName string `json:"OriginalName"`
+	// ModTime is the modification time of the source file, if any
+	ModTime time.Time `json:"ModTime"`
 	// Size is the size in bytes
 	Size int64 `json:"Size"`
+	// SourceSize is the size in bytes of the source file, if any, before its contents were rewritten
+	SourceSize int64 `json:"SourceSize"`
 	// MD5 is the hash of the file contents
 	MD5 string `json:"MD5"`
+	// ContentType is the MIME type of the file contents, detected when uploaded if empty
+	ContentType string `json:"ContentType"`
 	// Replaces is the URI of an image to replace
 	Replaces string `json:"Replaces"`
 	// AlbumKey is the album into which the file will be uploaded
 	AlbumKey string `json:"AlbumKey"`
+	// AlbumPath is the path of folder names ending in the album name, if the album was found by path
+	AlbumPath string `json:"AlbumPath"`
+	// CreateAlbum, if AlbumKey is empty, creates the album when the file is uploaded and returns its key
+	CreateAlbum func(context.Context) (string, error) `json:"-"`
+	// Title is the title of the image
+	Title string `json:"Title"`
+	// Caption is the caption of the image
+	Caption string `json:"Caption"`
+	// Keywords are the keywords of the image
+	Keywords []string `json:"Keywords"`
+	// Hidden hides the image in the album
+	Hidden bool `json:"Hidden"`
+	// Location is the geolocation of the image
+	Location *Location `json:"Location"`
 	// Reader holds the image data for uploading
 	Reader io.Reader `json:"-"`
+	// Skipped is the reason, wrapping ErrSkip, the Uploadable is not to be uploaded
+	// A skipped Uploadable is reported by `Uploads` without being uploaded
+	Skipped error `json:"-"`
+}
+
+// Location is the geographic position of an image
+type Location struct {
+	// Latitude in decimal degrees
+	Latitude float64 `json:"Latitude"`
+	// Longitude in decimal degrees
+	Longitude float64 `json:"Longitude"`
+	// Altitude in meters
+	Altitude float64 `json:"Altitude"`
 }
 
 // Upload is the object details for the uploaded object
@@ -516,4 +554,16 @@ type Upload struct {
 	URL string `json:"URL"`
 	// Uploadable is the object being uploaded
 	Uploadable *Uploadable `json:"Uploadable"`
+	// Err is the reason the upload failed or was skipped
+	Err error `json:"-"`
+}
+
+// Summary groups the results of a batch of uploads by outcome
+type Summary struct {
+	// Succeeded are the uploads completed without error
+	Succeeded []*Upload `json:"Succeeded"`
+	// Failed are the uploads which could not be completed
+	Failed []*Upload `json:"Failed"`
+	// Skipped are the uploads intentionally not attempted
+	Skipped []*Upload `json:"Skipped"`
 }
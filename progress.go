@@ -0,0 +1,130 @@
+package smugmug
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// Progress reports the bytes sent for an Uploadable and for the batch of uploads it belongs to
+type Progress struct {
+	// Uploadable is the object being uploaded
+	Uploadable *Uploadable `json:"Uploadable"`
+	// Sent is the number of bytes of the Uploadable sent
+	Sent int64 `json:"Sent"`
+	// BatchSent is the number of bytes sent for all Uploadables in the batch
+	BatchSent int64 `json:"BatchSent"`
+	// BatchSize is the size reported by a SizedUploadables batch or, if larger, the number of bytes of all
+	// Uploadables in the batch started so far
+	BatchSize int64 `json:"BatchSize"`
+	// Throughput is the number of bytes sent per second for the batch
+	Throughput float64 `json:"Throughput"`
+	// ETA is the estimated time remaining to send BatchSize bytes
+	// An Uploadable is counted only once an upload starts so unless the batch is a SizedUploadables the
+	// ETA covers just the uploads in progress, not those still waiting
+	ETA time.Duration `json:"ETA"`
+}
+
+// ProgressFunc is called as bytes are sent to SmugMug
+// Calls are serialized but made from the upload goroutines so the function should return quickly
+type ProgressFunc func(*Progress)
+
+// WithProgress configures a function to receive upload progress
+func WithProgress(fn ProgressFunc) Option {
+	return func(c *Client) error {
+		c.progress = fn
+		return nil
+	}
+}
+
+// tracker accumulates the progress of a batch of uploads
+type tracker struct {
+	fn    ProgressFunc
+	start time.Time
+	mu    sync.Mutex
+	sent  int64
+	size  int64
+	total int64
+}
+
+func newTracker(fn ProgressFunc, total int64) *tracker {
+	if fn == nil {
+		return nil
+	}
+	return &tracker{fn: fn, start: time.Now(), total: total}
+}
+
+// add includes the Uploadable in the size of the batch
+func (t *tracker) add(up *Uploadable) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.size += up.Size
+}
+
+// update records `n` bytes sent (negative if the Uploadable is being resent) and reports the progress
+func (t *tracker) update(up *Uploadable, sent, n int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sent += n
+	progress := &Progress{
+		Uploadable: up,
+		Sent:       sent,
+		BatchSent:  t.sent,
+		BatchSize:  max(t.size, t.total),
+	}
+	if elapsed := time.Since(t.start).Seconds(); elapsed > 0 {
+		progress.Throughput = float64(t.sent) / elapsed
+	}
+	if progress.Throughput > 0 {
+		remaining := float64(progress.BatchSize-t.sent) / progress.Throughput
+		progress.ETA = time.Duration(remaining * float64(time.Second))
+	}
+	t.fn(progress)
+}
+
+// reader wraps the Uploadable's Reader to report the bytes read
+func (t *tracker) reader(up *Uploadable, r io.Reader) *progressReader {
+	if t == nil || r == nil {
+		return nil
+	}
+	return &progressReader{r: r, up: up, tracker: t}
+}
+
+// progressReader reports the bytes read from the underlying reader
+type progressReader struct {
+	r       io.Reader
+	up      *Uploadable
+	tracker *tracker
+	sent    int64
+}
+
+// Read reads from the underlying reader and reports the progress
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.sent += int64(n)
+		p.tracker.update(p.up, p.sent, int64(n))
+	}
+	return n, err
+}
+
+// Close closes the underlying reader if it is an io.Closer
+func (p *progressReader) Close() error {
+	if c, ok := p.r.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// rewind discounts the bytes sent before the Uploadable is resent
+func (p *progressReader) rewind() {
+	if p == nil || p.sent == 0 {
+		return
+	}
+	sent := p.sent
+	p.sent = 0
+	p.tracker.update(p.up, 0, -sent)
+}
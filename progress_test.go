@@ -0,0 +1,167 @@
+package smugmug_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bzimmer/smugmug"
+)
+
+func TestProgress(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := io.Copy(io.Discard, r.Body)
+		a.NoError(err)
+		http.ServeFile(w, r, "testdata/upload_CVvj69L.json")
+	}))
+	defer svr.Close()
+
+	var mu sync.Mutex
+	var progress []*smugmug.Progress
+	mg, err := smugmug.NewClient(
+		smugmug.WithUploadURL(svr.URL),
+		smugmug.WithProgress(func(p *smugmug.Progress) {
+			mu.Lock()
+			defer mu.Unlock()
+			progress = append(progress, p)
+		}))
+	a.NoError(err)
+
+	body := strings.Repeat("a", 1024)
+	uploadables := sliceUploadables{
+		{Name: "DSC0001.jpg", AlbumKey: "7dFHSm", Size: 1024, Reader: strings.NewReader(body)},
+		{Name: "DSC0002.jpg", AlbumKey: "7dFHSm", Size: 1024, Reader: strings.NewReader(body)},
+	}
+	summary, err := smugmug.Summarize(mg.Upload.Uploads(context.TODO(), uploadables))
+	a.NoError(err)
+	a.Len(summary.Succeeded, 2)
+
+	mu.Lock()
+	defer mu.Unlock()
+	a.NotEmpty(progress)
+	sent := make(map[string]int64)
+	for _, p := range progress {
+		a.LessOrEqual(p.BatchSent, p.BatchSize)
+		a.GreaterOrEqual(p.ETA, time.Duration(0))
+		sent[p.Uploadable.Name] = p.Sent
+	}
+	a.Equal(int64(1024), sent["DSC0001.jpg"])
+	a.Equal(int64(1024), sent["DSC0002.jpg"])
+	last := progress[len(progress)-1]
+	a.Equal(int64(2048), last.BatchSent)
+	a.Equal(int64(2048), last.BatchSize)
+	a.Zero(last.ETA)
+}
+
+func TestProgressRetry(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	var once sync.Once
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := io.Copy(io.Discard, r.Body)
+		a.NoError(err)
+		failed := false
+		once.Do(func() { failed = true })
+		if failed {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		http.ServeFile(w, r, "testdata/upload_CVvj69L.json")
+	}))
+	defer svr.Close()
+
+	var last *smugmug.Progress
+	mg, err := smugmug.NewClient(
+		smugmug.WithUploadURL(svr.URL),
+		smugmug.WithRetries(1, 0),
+		smugmug.WithProgress(func(p *smugmug.Progress) {
+			last = p
+		}))
+	a.NoError(err)
+
+	up := &smugmug.Uploadable{Name: "DSC0001.jpg", AlbumKey: "7dFHSm", Size: 14, Reader: strings.NewReader("this is a test")}
+	upload, err := mg.Upload.Upload(context.TODO(), up)
+	a.NoError(err)
+	a.NotNil(upload)
+	a.NotNil(last)
+	a.Equal(int64(14), last.Sent)
+	a.Equal(int64(14), last.BatchSent)
+}
+
+func TestProgressTotal(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := io.Copy(io.Discard, r.Body)
+		a.NoError(err)
+		http.ServeFile(w, r, "testdata/upload_CVvj69L.json")
+	}))
+	defer svr.Close()
+
+	var mu sync.Mutex
+	var progress []*smugmug.Progress
+	mg, err := smugmug.NewClient(
+		smugmug.WithUploadURL(svr.URL),
+		smugmug.WithConcurrency(1),
+		smugmug.WithProgress(func(p *smugmug.Progress) {
+			mu.Lock()
+			defer mu.Unlock()
+			progress = append(progress, p)
+		}))
+	a.NoError(err)
+
+	body := strings.Repeat("a", 1024)
+	uploadables := sliceUploadables{
+		{Name: "DSC0001.jpg", AlbumKey: "7dFHSm", Size: 1024, Reader: strings.NewReader(body)},
+		{Name: "DSC0002.jpg", AlbumKey: "7dFHSm", Size: 1024, Reader: strings.NewReader(body)},
+	}
+	summary, err := smugmug.Summarize(mg.Upload.Uploads(context.TODO(), smugmug.Sized(uploadables, 4096)))
+	a.NoError(err)
+	a.Len(summary.Succeeded, 2)
+
+	mu.Lock()
+	a.NotEmpty(progress)
+	for _, p := range progress {
+		// the total is known before the second upload starts
+		a.Equal(int64(4096), p.BatchSize)
+	}
+	last := progress[len(progress)-1]
+	a.Equal(int64(2048), last.BatchSent)
+	a.Positive(last.ETA)
+	progress = nil
+	mu.Unlock()
+
+	// the size applies only to its batch
+	for _, up := range uploadables {
+		_, err = up.Reader.(io.Seeker).Seek(0, io.SeekStart)
+		a.NoError(err)
+	}
+	summary, err = smugmug.Summarize(mg.Upload.Uploads(context.TODO(), uploadables))
+	a.NoError(err)
+	a.Len(summary.Succeeded, 2)
+	_, err = uploadables[0].Reader.(io.Seeker).Seek(0, io.SeekStart)
+	a.NoError(err)
+	upload, err := mg.Upload.Upload(context.TODO(), uploadables[0])
+	a.NoError(err)
+	a.NotNil(upload)
+
+	mu.Lock()
+	defer mu.Unlock()
+	a.NotEmpty(progress)
+	for _, p := range progress {
+		a.LessOrEqual(p.BatchSize, int64(2048))
+	}
+	a.Equal(int64(1024), progress[len(progress)-1].BatchSize)
+}
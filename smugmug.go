@@ -2,6 +2,7 @@ package smugmug
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -9,8 +10,10 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/mrjones/oauth"
+	"golang.org/x/sync/semaphore"
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
 )
@@ -55,6 +58,20 @@ type Client struct {
 	baseURL     string
 	uploadURL   string
 	concurrency int
+	maxInFlight int64
+	inflight    *semaphore.Weighted
+	retries     int
+	backoff     time.Duration
+	progress    ProgressFunc
+	journal     *Journal
+	bandwidth   *Bandwidth
+
+	verifyInterval time.Duration
+	verifyTimeout  time.Duration
+
+	continueOnError bool
+	minConcurrency  int
+	maxConcurrency  int
 
 	User   *UserService
 	Node   *NodeService
@@ -80,6 +97,9 @@ func withServices() Option {
 		if c.concurrency == 0 {
 			c.concurrency = concurrency
 		}
+		if c.maxInFlight > 0 {
+			c.inflight = semaphore.NewWeighted(c.maxInFlight)
+		}
 		return nil
 	}
 }
@@ -95,6 +115,48 @@ func WithConcurrency(concurrency int) Option {
 	}
 }
 
+// WithMaxInFlight limits the total size in bytes of all Uploadables being uploaded concurrently
+// A value of zero (the default) does not limit the size
+func WithMaxInFlight(size int64) Option {
+	return func(c *Client) error {
+		if size < 0 {
+			return errors.New("max in flight must not be negative")
+		}
+		c.maxInFlight = size
+		return nil
+	}
+}
+
+// WithRetries configures the number of times a transient upload failure is retried
+// The wait between attempts starts at `backoff` and doubles with each attempt
+func WithRetries(retries int, backoff time.Duration) Option {
+	return func(c *Client) error {
+		if retries < 0 {
+			return errors.New("retries must not be negative")
+		}
+		c.retries = retries
+		c.backoff = backoff
+		return nil
+	}
+}
+
+// WithContinueOnError configures `Uploads` to report a failed upload in its result rather than
+// cancelling the remaining uploads
+func WithContinueOnError(continueOnError bool) Option {
+	return func(c *Client) error {
+		c.continueOnError = continueOnError
+		return nil
+	}
+}
+
+// WithJournal records completed uploads in the journal and skips Uploadables already recorded
+func WithJournal(journal *Journal) Option {
+	return func(c *Client) error {
+		c.journal = journal
+		return nil
+	}
+}
+
 // WithPretty enable indention of the req/res from SmugMug (useful for debugging)
 func WithPretty(pretty bool) Option {
 	return func(c *Client) error {
@@ -160,6 +222,14 @@ func WithFilters(filters ...string) APIOption {
 	}
 }
 
+// WithFilterURIs queries SmugMug for only the uris in the filter list
+func WithFilterURIs(uris ...string) APIOption {
+	return func(v url.Values) error {
+		v.Set("_filteruri", strings.Join(uris, ","))
+		return nil
+	}
+}
+
 // WithSearch queries Smugmug for the text within the given scope
 // The scope is a URI representing a user, album, node, or folder
 func WithSearch(scope, text string) APIOption {
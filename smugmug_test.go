@@ -30,6 +30,7 @@ func TestAPIOptions(t *testing.T) {
 	opts := []smugmug.APIOption{
 		smugmug.WithExpansions("Image", "Album"),
 		smugmug.WithFilters("Name"),
+		smugmug.WithFilterURIs("Image"),
 		smugmug.WithSorting("Ascending", "LastUpdated"),
 		smugmug.WithSearch("/api/v2/user/cmac", "Marmot"),
 	}
@@ -40,6 +41,7 @@ func TestAPIOptions(t *testing.T) {
 	}
 
 	a.Equal("Name", v.Get("_filter"))
+	a.Equal("Image", v.Get("_filteruri"))
 	a.Equal("Image,Album", v.Get("_expand"))
 	a.Equal("Ascending", v.Get("SortDirection"))
 	a.Equal("LastUpdated", v.Get("SortMethod"))
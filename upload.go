@@ -1,17 +1,26 @@
 package smugmug
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
+	"path"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"golang.org/x/sync/errgroup"
 )
 
+// ErrSkip indicates an Uploadable was intentionally not uploaded
+var ErrSkip = errors.New("skip")
+
 // UploadService is the API for the upload endpoint
 type UploadService service
 
@@ -21,18 +30,151 @@ type Uploadables interface {
 	Uploadables(context.Context) (<-chan *Uploadable, <-chan error)
 }
 
+// SizedUploadables is implemented by Uploadables which know the total size of their Uploadables
+// Uploadables are counted only as their uploads start so the size is needed for a meaningful progress ETA
+type SizedUploadables interface {
+	Uploadables
+	// Size returns the total number of bytes expected
+	Size() int64
+}
+
+type sizedUploadables struct {
+	uploadables Uploadables
+	size        int64
+}
+
+func (s *sizedUploadables) Uploadables(ctx context.Context) (<-chan *Uploadable, <-chan error) {
+	return s.uploadables.Uploadables(ctx)
+}
+
+func (s *sizedUploadables) Size() int64 {
+	return s.size
+}
+
+// Sized returns the Uploadables as a SizedUploadables of `size` bytes, eg the size of the files to upload
+func Sized(uploadables Uploadables, size int64) SizedUploadables {
+	return &sizedUploadables{uploadables: uploadables, size: size}
+}
+
 // Upload an image to an album
+// Transient failures are retried if configured with `WithRetries` and the Uploadable's Reader is an io.Seeker
+// If configured with `WithVerification` an image which does not match the Uploadable returns a *VerificationError
 func (s *UploadService) Upload(ctx context.Context, up *Uploadable) (*Upload, error) {
+	if up.Skipped != nil {
+		return nil, up.Skipped
+	}
+	tr := newTracker(s.client.progress, 0)
+	tr.add(up)
+	return s.send(ctx, up, tr)
+}
+
+func (s *UploadService) send(ctx context.Context, up *Uploadable, tr *tracker) (*Upload, error) {
+	upload, err := s.put(ctx, up, tr)
+	if err != nil {
+		return nil, err
+	}
+	return s.complete(ctx, upload)
+}
+
+// complete verifies and journals an uploaded image
+// It is called once the upload has finished sending so waiting for processing holds no in-flight bytes
+func (s *UploadService) complete(ctx context.Context, upload *Upload) (*Upload, error) {
+	if s.client.verifyInterval > 0 {
+		if err := s.verify(ctx, upload); err != nil {
+			return nil, err
+		}
+	}
+	if s.client.journal != nil {
+		if err := s.client.journal.Record(upload); err != nil {
+			return nil, err
+		}
+	}
+	return upload, nil
+}
+
+// put sends the Uploadable to SmugMug, retrying transient failures
+func (s *UploadService) put(ctx context.Context, up *Uploadable, tr *tracker) (*Upload, error) {
+	if up.AlbumKey == "" && up.CreateAlbum != nil {
+		albumKey, err := up.CreateAlbum(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create album `%s` with error %w", up.AlbumPath, err)
+		}
+		up.AlbumKey = albumKey
+	}
 	if up.AlbumKey == "" {
 		return nil, errors.New("missing albumKey")
 	}
 
+	// verify the media before any bytes are sent
+	mt, err := mediaType(up)
+	if err != nil {
+		return nil, err
+	}
+	if mt != nil {
+		up.ContentType = mt.ContentType
+	}
+
+	if s.client.inflight != nil {
+		// an Uploadable larger than the ceiling is allowed but only when nothing else is in flight
+		n := min(up.Size, s.client.maxInFlight)
+		if err := s.client.inflight.Acquire(ctx, n); err != nil {
+			return nil, err
+		}
+		defer s.client.inflight.Release(n)
+	}
+
+	var body io.Reader = up.Reader
+	pr := tr.reader(up, up.Reader)
+	if pr != nil {
+		body = pr
+	}
+	body = s.client.bandwidth.Reader(ctx, body)
+
+	for attempt := 0; ; attempt++ {
+		var upload *Upload
+		rb := newRequestBody(body)
+		var sent bool
+		upload, sent, err = s.upload(ctx, up, rb)
+		if err == nil {
+			return upload, nil
+		}
+		// a request which could not be sent will not succeed on a subsequent attempt
+		if attempt >= s.client.retries || !sent || !transient(ctx, err) {
+			break
+		}
+		// the transport may still be sending the body after the response arrives
+		if err = rb.wait(ctx); err != nil {
+			return nil, err
+		}
+		if !rewind(up) {
+			break
+		}
+		pr.rewind()
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(s.client.backoff << attempt):
+		}
+	}
+	return nil, fmt.Errorf("failed to upload file `%s` with error %w", up.Name, err)
+}
+
+// upload sends the Uploadable, returning true if the request was sent and its body handed to the transport
+func (s *UploadService) upload(ctx context.Context, up *Uploadable, rb *requestBody) (*Upload, bool, error) {
 	// https://api.smugmug.com/services/api/?method=upload
 
-	uri := fmt.Sprintf("%s/photo.jpg", s.client.uploadURL)
-	req, err := http.NewRequestWithContext(ctx, http.MethodPut, uri, up.Reader)
+	var body io.Reader
+	if rb != nil {
+		body = rb
+	}
+	uri := s.client.uploadURL + "/"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, uri, body)
 	if err != nil {
-		return nil, err
+		return nil, false, err
+	}
+	if body != nil {
+		// the body is streamed so the length is not known to the http client
+		req.ContentLength = up.Size
 	}
 
 	headers := map[string]string{
@@ -46,9 +188,29 @@ func (s *UploadService) Upload(ctx context.Context, up *Uploadable) (*Upload, er
 		"X-Smug-FileName":     url.PathEscape(up.Name),
 	}
 
+	if up.ContentType != "" {
+		headers["Content-Type"] = up.ContentType
+	}
 	if up.Replaces != "" {
 		headers["X-Smug-ImageUri"] = up.Replaces
 	}
+	if up.Title != "" {
+		headers["X-Smug-Title"] = url.PathEscape(up.Title)
+	}
+	if up.Caption != "" {
+		headers["X-Smug-Caption"] = url.PathEscape(up.Caption)
+	}
+	if len(up.Keywords) > 0 {
+		headers["X-Smug-Keywords"] = url.PathEscape(strings.Join(up.Keywords, "; "))
+	}
+	if up.Hidden {
+		headers["X-Smug-Hidden"] = strconv.FormatBool(up.Hidden)
+	}
+	if up.Location != nil {
+		headers["X-Smug-Latitude"] = strconv.FormatFloat(up.Location.Latitude, 'f', -1, 64)
+		headers["X-Smug-Longitude"] = strconv.FormatFloat(up.Location.Longitude, 'f', -1, 64)
+		headers["X-Smug-Altitude"] = strconv.FormatFloat(up.Location.Altitude, 'f', -1, 64)
+	}
 
 	for key, val := range headers {
 		req.Header.Set(key, val)
@@ -56,14 +218,99 @@ func (s *UploadService) Upload(ctx context.Context, up *Uploadable) (*Upload, er
 
 	t := time.Now()
 	ur := &uploadResponse{}
-	err = s.client.do(req, ur)
-	if err != nil {
-		return nil, fmt.Errorf("failed to upload file `%s` with error %w", up.Name, err)
+	if err = s.client.do(req, ur); err != nil {
+		return nil, true, err
+	}
+	return ur.Upload(up, time.Since(t)), true, nil
+}
+
+// transient returns true if the error is likely to succeed on a subsequent attempt
+func transient(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if congested(err) {
+		return true
+	}
+	var uerr *url.Error
+	return errors.As(err, &uerr)
+}
+
+// requestBody is the body of a single upload attempt
+// The transport closes the body, possibly after the response has been returned, so a retry
+// waits for the close before rewinding the reader shared by all attempts
+type requestBody struct {
+	r      io.Reader
+	mu     sync.Mutex
+	closed bool
+	done   chan struct{}
+}
+
+func newRequestBody(r io.Reader) *requestBody {
+	if r == nil {
+		return nil
+	}
+	return &requestBody{r: r, done: make(chan struct{})}
+}
+
+// Read reads from the underlying reader until the body is closed
+func (b *requestBody) Read(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return 0, io.ErrClosedPipe
+	}
+	return b.r.Read(p)
+}
+
+// Close closes the underlying reader if it is an io.Closer and releases any waiting retry
+func (b *requestBody) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil
+	}
+	b.closed = true
+	defer close(b.done)
+	if c, ok := b.r.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// wait blocks until the transport has closed the body
+func (b *requestBody) wait(ctx context.Context) error {
+	if b == nil {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-b.done:
+		return nil
 	}
-	return ur.Upload(up, time.Since(t)), nil
+}
+
+// rewind positions the Uploadable's Reader at the start of the data, returning false if not possible
+func rewind(up *Uploadable) bool {
+	if up.Reader == nil {
+		return true
+	}
+	seeker, ok := up.Reader.(io.Seeker)
+	if !ok {
+		return false
+	}
+	_, err := seeker.Seek(0, io.SeekStart)
+	return err == nil
 }
 
 // Uploads consumes Uploadables from uploadables, uploads them to SmugMug returning status in Upload instances
+// By default the first failed upload cancels all others; if configured with `WithContinueOnError` a failed
+// upload is returned as an Upload with a non-nil Err and the remaining uploads continue
+// If configured with `WithAdaptiveConcurrency` the number of concurrent uploads changes as the batch progresses
+// If configured with `WithJournal` an Uploadable found in the journal is returned as an Upload with an ErrSkip Err
+// An Uploadable with a non-nil Skipped is returned as an Upload with Skipped as the Err
+// If `uploadables` is a SizedUploadables its size is reported as the BatchSize of the progress
 func (s *UploadService) Uploads(ctx context.Context, uploadables Uploadables) (<-chan *Upload, <-chan error) {
 	updc := make(chan *Upload)
 	errc := make(chan error, 1)
@@ -78,8 +325,18 @@ func (s *UploadService) Uploads(ctx context.Context, uploadables Uploadables) (<
 			return err
 		}
 	})
-	for range s.client.concurrency {
-		grp.Go(s.uploads(ctx, uploadablesc, updc))
+	var total int64
+	if sized, ok := uploadables.(SizedUploadables); ok {
+		total = sized.Size()
+	}
+	tr := newTracker(s.client.progress, total)
+	ac := newAdaptive(s.client.minConcurrency, s.client.maxConcurrency, s.client.concurrency)
+	workers := s.client.concurrency
+	if ac != nil {
+		workers = ac.maximum
+	}
+	for range workers {
+		grp.Go(s.uploads(ctx, tr, ac, uploadablesc, updc))
 	}
 
 	go func() {
@@ -94,19 +351,41 @@ func (s *UploadService) Uploads(ctx context.Context, uploadables Uploadables) (<
 }
 
 func (s *UploadService) uploads(
-	ctx context.Context, uploadablesc <-chan *Uploadable, updc chan<- *Upload) func() error {
+	ctx context.Context, tr *tracker, ac *adaptive, uploadablesc <-chan *Uploadable, updc chan<- *Upload,
+) func() error {
 	return func() error {
 		for {
+			sl, err := ac.acquire(ctx)
+			if err != nil {
+				return err
+			}
 			select {
 			case <-ctx.Done():
+				ac.cancel()
 				return ctx.Err()
 			case up, ok := <-uploadablesc:
 				if !ok {
+					ac.cancel()
 					return nil
 				}
-				upload, err := s.Upload(ctx, up)
+				upload, err := s.skipped(up)
+				if upload == nil {
+					tr.add(up)
+					t := time.Now()
+					upload, err = s.put(ctx, up, tr)
+					// processing is not counted as latency of the upload
+					ac.release(sl, up.Size, time.Since(t), err)
+					if err == nil {
+						upload, err = s.complete(ctx, upload)
+					}
+				} else {
+					ac.cancel()
+				}
 				if err != nil {
-					return err
+					if !s.client.continueOnError || ctx.Err() != nil {
+						return err
+					}
+					upload = &Upload{Uploadable: up, Err: err}
 				}
 				select {
 				case <-ctx.Done():
@@ -118,6 +397,107 @@ func (s *UploadService) uploads(
 	}
 }
 
+// skipped returns an Upload with an ErrSkip error if the Uploadable was skipped or already uploaded
+func (s *UploadService) skipped(up *Uploadable) (*Upload, error) {
+	if up.Skipped != nil {
+		return &Upload{Uploadable: up, Err: up.Skipped}, nil
+	}
+	if s.client.journal == nil {
+		return nil, nil //nolint:nilnil // no journal is configured
+	}
+	entry, ok := s.client.journal.Confirmed(up)
+	if !ok {
+		return nil, nil //nolint:nilnil // the Uploadable was not found in the journal
+	}
+	return &Upload{
+		Uploadable: up,
+		ImageURI:   entry.ImageURI,
+		Err:        fmt.Errorf("%w: `%s` found in journal", ErrSkip, up.Path),
+	}, nil
+}
+
+// Summarize consumes all the results from `Uploads` and groups them by outcome
+func Summarize(uploadc <-chan *Upload, errc <-chan error) (*Summary, error) {
+	summary := &Summary{}
+	for upload := range uploadc {
+		switch {
+		case upload.Err == nil:
+			summary.Succeeded = append(summary.Succeeded, upload)
+		case errors.Is(upload.Err, ErrSkip):
+			summary.Skipped = append(summary.Skipped, upload)
+		default:
+			summary.Failed = append(summary.Failed, upload)
+		}
+	}
+	return summary, <-errc
+}
+
+// UploadFromURL requests SmugMug fetch the media at `rawURL` and add it to the album `albumKey`
+// The media is transferred directly to SmugMug rather than through this client
+func (s *UploadService) UploadFromURL(
+	ctx context.Context, albumKey, rawURL string, options ...APIOption) (*Upload, error) {
+	if albumKey == "" {
+		return nil, errors.New("missing albumKey")
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported url scheme {%s}", u.Scheme)
+	}
+
+	// the filename is taken from the url so it must name a file rather than a directory
+	name := path.Base(u.Path)
+	if name == "." || name == "/" || strings.HasSuffix(u.Path, "/") {
+		return nil, fmt.Errorf("url `%s` does not name a file", rawURL)
+	}
+
+	up := &Uploadable{Name: name, AlbumKey: albumKey}
+	body, err := json.Marshal(map[string]string{"Uri": u.String(), "FileName": up.Name})
+	if err != nil {
+		return nil, err
+	}
+
+	uri := fmt.Sprintf("album/%s!uploadfromuri", albumKey)
+	req, err := s.client.newRequestWithBody(ctx, http.MethodPost, uri, bytes.NewReader(body), options)
+	if err != nil {
+		return nil, err
+	}
+
+	t := time.Now()
+	res := &uploadFromURIResponse{}
+	if err = s.client.do(req, res); err != nil {
+		return nil, fmt.Errorf("failed to upload url `%s` with error %w", rawURL, err)
+	}
+	return res.Upload(up, time.Since(t)), nil
+}
+
+type uploadFromURIResponse struct {
+	Response struct {
+		UploadedImage struct {
+			StatusImageReplaceURI string `json:"StatusImageReplaceUri"`
+			ImageURI              string `json:"ImageUri"`
+			AlbumImageURI         string `json:"AlbumImageUri"`
+			URL                   string `json:"URL"`
+		} `json:"Image"`
+	} `json:"Response"`
+	Code    int    `json:"Code"`
+	Message string `json:"Message"`
+}
+
+func (u *uploadFromURIResponse) Upload(up *Uploadable, elapsed time.Duration) *Upload {
+	return &Upload{
+		Uploadable:    up,
+		Status:        u.Message,
+		Method:        "uploadfromuri",
+		Elapsed:       elapsed,
+		URL:           u.Response.UploadedImage.URL,
+		ImageURI:      u.Response.UploadedImage.ImageURI,
+		AlbumImageURI: u.Response.UploadedImage.AlbumImageURI,
+	}
+}
+
 type uploadResponse struct {
 	Stat          string `json:"stat"`
 	Method        string `json:"method"`
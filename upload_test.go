@@ -2,9 +2,15 @@ package smugmug_test
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -58,7 +64,7 @@ func TestUpload(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 			mux := http.NewServeMux()
-			mux.HandleFunc("/photo.jpg", func(w http.ResponseWriter, r *http.Request) {
+			mux.HandleFunc("/{$}", func(w http.ResponseWriter, r *http.Request) {
 				if tt.status != 0 {
 					w.WriteHeader(tt.status)
 					return
@@ -192,3 +198,447 @@ func TestUploads(t *testing.T) {
 		})
 	}
 }
+
+func TestUploadMaxInFlight(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	body := "this is a test"
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		a.Equal(int64(len(body)), r.ContentLength)
+		b, err := io.ReadAll(r.Body)
+		a.NoError(err)
+		a.Equal(body, string(b))
+		http.ServeFile(w, r, "testdata/upload_CVvj69L.json")
+	}))
+	defer svr.Close()
+
+	mg, err := smugmug.NewClient(
+		smugmug.WithUploadURL(svr.URL),
+		smugmug.WithConcurrency(4),
+		smugmug.WithMaxInFlight(4))
+	a.NoError(err)
+
+	for range 3 {
+		// the uploadable is larger than the ceiling but must not block forever
+		up := &smugmug.Uploadable{
+			Name:     "DSC33556.jpg",
+			AlbumKey: "7dFHSm",
+			Size:     int64(len(body)),
+			Reader:   strings.NewReader(body),
+		}
+		upload, err := mg.Upload.Upload(context.TODO(), up)
+		a.NoError(err)
+		a.NotNil(upload)
+	}
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	upload, err := mg.Upload.Upload(ctx, &smugmug.Uploadable{AlbumKey: "7dFHSm", Size: 1})
+	a.Error(err)
+	a.Nil(upload)
+
+	mg, err = smugmug.NewClient(smugmug.WithMaxInFlight(-1))
+	a.Error(err)
+	a.Nil(mg)
+}
+
+func TestUploadRetries(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		failures int32
+		status   int
+		retries  int
+		reader   func() io.Reader
+		attempts int32
+		err      bool
+	}{
+		{
+			name:     "retry succeeds",
+			failures: 2,
+			status:   http.StatusServiceUnavailable,
+			retries:  2,
+			reader:   func() io.Reader { return strings.NewReader("this is a test") },
+			attempts: 3,
+		},
+		{
+			name:     "retries exhausted",
+			failures: 3,
+			status:   http.StatusTooManyRequests,
+			retries:  2,
+			reader:   func() io.Reader { return strings.NewReader("this is a test") },
+			attempts: 3,
+			err:      true,
+		},
+		{
+			name:     "not transient",
+			failures: 1,
+			status:   http.StatusForbidden,
+			retries:  2,
+			reader:   func() io.Reader { return strings.NewReader("this is a test") },
+			attempts: 1,
+			err:      true,
+		},
+		{
+			name:     "not seekable",
+			failures: 1,
+			status:   http.StatusBadGateway,
+			retries:  2,
+			reader:   func() io.Reader { return io.LimitReader(strings.NewReader("this is a test"), 14) },
+			attempts: 1,
+			err:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			var attempts atomic.Int32
+			svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, err := io.ReadAll(r.Body)
+				a.NoError(err)
+				a.Equal("this is a test", string(b))
+				if attempts.Add(1) <= tt.failures {
+					w.WriteHeader(tt.status)
+					return
+				}
+				http.ServeFile(w, r, "testdata/upload_CVvj69L.json")
+			}))
+			defer svr.Close()
+
+			mg, err := smugmug.NewClient(
+				smugmug.WithUploadURL(svr.URL),
+				smugmug.WithRetries(tt.retries, time.Millisecond))
+			a.NoError(err)
+
+			up := &smugmug.Uploadable{Name: "DSC33556.jpg", AlbumKey: "7dFHSm", Size: 14, Reader: tt.reader()}
+			upload, err := mg.Upload.Upload(context.TODO(), up)
+			a.Equal(tt.attempts, attempts.Load())
+			if tt.err {
+				a.Error(err)
+				a.Nil(upload)
+				return
+			}
+			a.NoError(err)
+			a.NotNil(upload)
+		})
+	}
+
+	mg, err := smugmug.NewClient(smugmug.WithRetries(-1, 0))
+	assert.Error(t, err)
+	assert.Nil(t, mg)
+}
+
+func TestUploadRetriesNotSent(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	// a request which cannot be created is not retried, nor does it wait for the body to be closed
+	mg, err := smugmug.NewClient(
+		smugmug.WithUploadURL("http://bad host"),
+		smugmug.WithRetries(2, time.Millisecond))
+	a.NoError(err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	up := &smugmug.Uploadable{
+		Name: "DSC33556.jpg", AlbumKey: "7dFHSm", Size: 14, Reader: strings.NewReader("this is a test")}
+	upload, err := mg.Upload.Upload(ctx, up)
+	a.Error(err)
+	a.NotErrorIs(err, context.DeadlineExceeded)
+	a.Nil(upload)
+	a.NoError(ctx.Err())
+}
+
+type sliceUploadables []*smugmug.Uploadable
+
+func (s sliceUploadables) Uploadables(ctx context.Context) (<-chan *smugmug.Uploadable, <-chan error) {
+	errc := make(chan error, 1)
+	uploadablesc := make(chan *smugmug.Uploadable)
+	go func() {
+		defer close(errc)
+		defer close(uploadablesc)
+		for _, up := range s {
+			select {
+			case <-ctx.Done():
+				errc <- ctx.Err()
+				return
+			case uploadablesc <- up:
+			}
+		}
+	}()
+	return uploadablesc, errc
+}
+
+func TestUploadsContinueOnError(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Smug-FileName") == "bad.jpg" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		http.ServeFile(w, r, "testdata/upload_CVvj69L.json")
+	}))
+	defer svr.Close()
+
+	uploadables := sliceUploadables{
+		{Name: "DSC0001.jpg", AlbumKey: "7dFHSm"},
+		{Name: "bad.jpg", AlbumKey: "7dFHSm"},
+		{Name: "DSC0002.jpg", AlbumKey: "7dFHSm"},
+		{Name: "DSC0003.jpg"},
+	}
+
+	mg, err := smugmug.NewClient(
+		smugmug.WithUploadURL(svr.URL),
+		smugmug.WithContinueOnError(true))
+	a.NoError(err)
+
+	summary, err := smugmug.Summarize(mg.Upload.Uploads(context.TODO(), uploadables))
+	a.NoError(err)
+	a.Len(summary.Succeeded, 2)
+	a.Len(summary.Failed, 2)
+	a.Empty(summary.Skipped)
+	for _, upload := range summary.Failed {
+		a.Error(upload.Err)
+		a.Contains([]string{"bad.jpg", "DSC0003.jpg"}, upload.Uploadable.Name)
+	}
+
+	mg, err = smugmug.NewClient(smugmug.WithUploadURL(svr.URL))
+	a.NoError(err)
+
+	summary, err = smugmug.Summarize(mg.Upload.Uploads(context.TODO(), uploadables))
+	a.Error(err)
+	a.NotNil(summary)
+	a.Empty(summary.Failed)
+}
+
+func TestUploadsSkipped(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	var attempts atomic.Int32
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		http.ServeFile(w, r, "testdata/upload_CVvj69L.json")
+	}))
+	defer svr.Close()
+
+	skip := fmt.Errorf("%w: filtered", smugmug.ErrSkip)
+	uploadables := sliceUploadables{
+		{Name: "DSC0001.jpg", AlbumKey: "7dFHSm"},
+		{Name: "DSC0002.jpg", Skipped: skip},
+	}
+
+	mg, err := smugmug.NewClient(smugmug.WithUploadURL(svr.URL))
+	a.NoError(err)
+
+	summary, err := smugmug.Summarize(mg.Upload.Uploads(context.TODO(), uploadables))
+	a.NoError(err)
+	a.Len(summary.Succeeded, 1)
+	a.Empty(summary.Failed)
+	a.Len(summary.Skipped, 1)
+	a.Equal("DSC0002.jpg", summary.Skipped[0].Uploadable.Name)
+	a.ErrorIs(summary.Skipped[0].Err, skip)
+	a.Equal(int32(1), attempts.Load())
+
+	upload, err := mg.Upload.Upload(context.TODO(), uploadables[1])
+	a.ErrorIs(err, smugmug.ErrSkip)
+	a.Nil(upload)
+	a.Equal(int32(1), attempts.Load())
+}
+
+func TestSummarize(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	uploadc := make(chan *smugmug.Upload, 3)
+	errc := make(chan error)
+	uploadc <- &smugmug.Upload{}
+	uploadc <- &smugmug.Upload{Err: errors.New("failed")}
+	uploadc <- &smugmug.Upload{Err: fmt.Errorf("journaled: %w", smugmug.ErrSkip)}
+	close(uploadc)
+	close(errc)
+
+	summary, err := smugmug.Summarize(uploadc, errc)
+	a.NoError(err)
+	a.Len(summary.Succeeded, 1)
+	a.Len(summary.Failed, 1)
+	a.Len(summary.Skipped, 1)
+}
+
+func TestUploadMetadata(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		a.Equal(url.PathEscape("A Title"), r.Header.Get("X-Smug-Title"))
+		a.Equal(url.PathEscape("A caption, with punctuation"), r.Header.Get("X-Smug-Caption"))
+		a.Equal(url.PathEscape("marmot; hiking"), r.Header.Get("X-Smug-Keywords"))
+		a.Equal("true", r.Header.Get("X-Smug-Hidden"))
+		a.Equal("47.6062", r.Header.Get("X-Smug-Latitude"))
+		a.Equal("-122.3321", r.Header.Get("X-Smug-Longitude"))
+		a.Equal("52.5", r.Header.Get("X-Smug-Altitude"))
+		http.ServeFile(w, r, "testdata/upload_CVvj69L.json")
+	}))
+	defer svr.Close()
+
+	mg, err := smugmug.NewClient(smugmug.WithUploadURL(svr.URL))
+	a.NoError(err)
+
+	up := &smugmug.Uploadable{
+		Name:     "DSC33556.jpg",
+		AlbumKey: "7dFHSm",
+		Title:    "A Title",
+		Caption:  "A caption, with punctuation",
+		Keywords: []string{"marmot", "hiking"},
+		Hidden:   true,
+		Location: &smugmug.Location{Latitude: 47.6062, Longitude: -122.3321, Altitude: 52.5},
+	}
+	upload, err := mg.Upload.Upload(context.TODO(), up)
+	a.NoError(err)
+	a.NotNil(upload)
+}
+
+func TestUploadFromURL(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	tests := []struct {
+		name     string
+		albumKey string
+		url      string
+		status   int
+		err      string
+	}{
+		{
+			name:     "success",
+			albumKey: "7dFHSm",
+			url:      "https://example.com/photos/DSC33556.jpg",
+		},
+		{
+			name: "missing album",
+			url:  "https://example.com/photos/DSC33556.jpg",
+			err:  "missing albumKey",
+		},
+		{
+			name:     "unsupported scheme",
+			albumKey: "7dFHSm",
+			url:      "ftp://example.com/photos/DSC33556.jpg",
+			err:      "unsupported url scheme",
+		},
+		{
+			name:     "invalid url",
+			albumKey: "7dFHSm",
+			url:      "https://example.com/%zz",
+			err:      "invalid URL escape",
+		},
+		{
+			name:     "no path",
+			albumKey: "7dFHSm",
+			url:      "https://example.com",
+			err:      "does not name a file",
+		},
+		{
+			name:     "root path",
+			albumKey: "7dFHSm",
+			url:      "https://example.com/",
+			err:      "does not name a file",
+		},
+		{
+			name:     "directory path",
+			albumKey: "7dFHSm",
+			url:      "https://example.com/photos/",
+			err:      "does not name a file",
+		},
+		{
+			name:     "server error",
+			albumKey: "7dFHSm",
+			url:      "https://example.com/photos/DSC33556.jpg",
+			status:   http.StatusNotFound,
+			err:      "failed to upload url",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			mux := http.NewServeMux()
+			mux.HandleFunc("/album/7dFHSm!uploadfromuri", func(w http.ResponseWriter, r *http.Request) {
+				if tt.status != 0 {
+					w.WriteHeader(tt.status)
+					return
+				}
+				a.Equal(http.MethodPost, r.Method)
+				var body map[string]string
+				a.NoError(json.NewDecoder(r.Body).Decode(&body))
+				a.Equal(tt.url, body["Uri"])
+				a.Equal("DSC33556.jpg", body["FileName"])
+				http.ServeFile(w, r, "testdata/upload_from_uri_7dFHSm.json")
+			})
+			svr := httptest.NewServer(mux)
+			defer svr.Close()
+
+			mg, err := smugmug.NewClient(smugmug.WithBaseURL(svr.URL))
+			a.NoError(err)
+
+			upload, err := mg.Upload.UploadFromURL(context.TODO(), tt.albumKey, tt.url)
+			if tt.err != "" {
+				a.Error(err)
+				a.Contains(err.Error(), tt.err)
+				a.Nil(upload)
+				return
+			}
+			a.NoError(err)
+			a.NotNil(upload)
+			a.Equal("/api/v2/image/Hq4Fc2x-0", upload.ImageURI)
+			a.Equal("/api/v2/album/7dFHSm/image/Hq4Fc2x-0", upload.AlbumImageURI)
+			a.Equal("DSC33556.jpg", upload.Uploadable.Name)
+			a.Equal("7dFHSm", upload.Uploadable.AlbumKey)
+		})
+	}
+}
+
+func TestUploadCreateAlbum(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		a.Equal("/api/v2/album/c1", r.Header.Get("X-Smug-AlbumUri"))
+		http.ServeFile(w, r, "testdata/upload_CVvj69L.json")
+	}))
+	defer svr.Close()
+
+	mg, err := smugmug.NewClient(smugmug.WithUploadURL(svr.URL))
+	a.NoError(err)
+
+	var created int
+	up := &smugmug.Uploadable{Name: "DSC0001.jpg", AlbumPath: "/Photos/2024",
+		CreateAlbum: func(context.Context) (string, error) {
+			created++
+			return "c1", nil
+		}}
+	upload, err := mg.Upload.Upload(context.TODO(), up)
+	a.NoError(err)
+	a.NotNil(upload)
+	a.Equal("c1", up.AlbumKey)
+	a.Equal(1, created)
+
+	// the album is created only if the album key is not known
+	_, err = mg.Upload.Upload(context.TODO(), up)
+	a.NoError(err)
+	a.Equal(1, created)
+
+	up = &smugmug.Uploadable{Name: "DSC0002.jpg", AlbumPath: "/Photos/fail",
+		CreateAlbum: func(context.Context) (string, error) {
+			return "", errors.New("forbidden")
+		}}
+	upload, err = mg.Upload.Upload(context.TODO(), up)
+	a.ErrorContains(err, "failed to create album `/Photos/fail`")
+	a.Nil(upload)
+}
@@ -0,0 +1,49 @@
+package archive
+
+import (
+	"context"
+	"errors"
+
+	"github.com/spf13/afero"
+
+	"github.com/bzimmer/smugmug"
+	"github.com/bzimmer/smugmug/uploadable/filesystem"
+)
+
+// entries calls `fn` with a filesystem containing, and the name of, each regular file in an archive
+type entries func(fn func(afs afero.Fs, name string) error) error
+
+type archiveUploadables struct {
+	entries    entries
+	uploadable filesystem.FsUploadable
+}
+
+func (p *archiveUploadables) Uploadables(ctx context.Context) (<-chan *smugmug.Uploadable, <-chan error) {
+	errc := make(chan error, 1)
+	uploadablesc := make(chan *smugmug.Uploadable)
+	go func() {
+		defer close(errc)
+		defer close(uploadablesc)
+		if err := p.entries(func(afs afero.Fs, name string) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			up, err := p.uploadable.Uploadable(afs, name)
+			if err != nil {
+				if errors.Is(err, filesystem.ErrSkip) {
+					return nil
+				}
+				return err
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case uploadablesc <- up:
+			}
+			return nil
+		}); err != nil {
+			errc <- err
+		}
+	}()
+	return uploadablesc, errc
+}
@@ -0,0 +1,73 @@
+package archive_test
+
+import (
+	"context"
+	"crypto/md5" //nolint:gosec // used to match md5 at smugmug
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bzimmer/smugmug"
+	"github.com/bzimmer/smugmug/uploadable/filesystem"
+)
+
+var modTime = time.Date(2024, time.March, 4, 10, 11, 12, 0, time.UTC) //nolint:gochecknoglobals // test fixture
+
+type entry struct {
+	name string
+	body string
+}
+
+func testEntries() []entry {
+	return []entry{
+		{name: "photos/DSC0001.jpg", body: "\xff\xd8\xff\xe1this is a test"},
+		{name: "photos/README.md", body: "not an image"},
+		{name: "photos/2024/DSC0002.JPG", body: "\xff\xd8\xff\xe1this is another test"},
+	}
+}
+
+func newFsUploadable(t *testing.T) filesystem.FsUploadable {
+	t.Helper()
+	fsu, err := filesystem.NewFsUploadable("7dFHSm")
+	assert.NoError(t, err)
+	fsu.Pre(filesystem.Extensions(".jpg"))
+	return fsu
+}
+
+func collect(uploadables smugmug.Uploadables) ([]*smugmug.Uploadable, error) {
+	var ups []*smugmug.Uploadable
+	upc, errc := uploadables.Uploadables(context.TODO())
+	for up := range upc {
+		ups = append(ups, up)
+	}
+	return ups, <-errc
+}
+
+func assertUploadables(a *assert.Assertions, ups []*smugmug.Uploadable) {
+	entries := testEntries()
+	expected := []entry{entries[0], entries[2]}
+	if !a.Len(ups, len(expected)) {
+		return
+	}
+	for i, up := range ups {
+		a.Equal(expected[i].name, up.Path)
+		a.Equal("7dFHSm", up.AlbumKey)
+		a.Equal(int64(len(expected[i].body)), up.Size)
+		a.Equal(fmt.Sprintf("%x", md5.Sum([]byte(expected[i].body))), up.MD5) //nolint:gosec // test
+		a.Equal("image/jpeg", up.ContentType)
+		a.True(modTime.Equal(up.ModTime))
+		// the contents can be read more than once for retries
+		for range 2 {
+			seeker, ok := up.Reader.(io.Seeker)
+			a.True(ok)
+			_, err := seeker.Seek(0, io.SeekStart)
+			a.NoError(err)
+			b, err := io.ReadAll(up.Reader)
+			a.NoError(err)
+			a.Equal(expected[i].body, string(b))
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package archive
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+
+	"github.com/bzimmer/smugmug"
+	"github.com/bzimmer/smugmug/uploadable/filesystem"
+)
+
+// NewTarUploadables returns a new instance of an Uploadables which creates Uploadable instances
+// from the regular files in the tar archive, decompressing it first if gzipped
+// The archive is read once as a stream so each entry is held in memory until its Uploadable is released
+func NewTarUploadables(r io.Reader, uploadable filesystem.FsUploadable) smugmug.Uploadables {
+	return &archiveUploadables{
+		uploadable: uploadable,
+		entries: func(fn func(afero.Fs, string) error) error {
+			tr, err := tarReader(r)
+			if err != nil {
+				return err
+			}
+			for {
+				hdr, err := tr.Next()
+				if err != nil {
+					if errors.Is(err, io.EOF) {
+						return nil
+					}
+					return err
+				}
+				if hdr.Typeflag != tar.TypeReg {
+					continue
+				}
+				if err := fn(newEntryFs(hdr, tr), hdr.Name); err != nil {
+					return err
+				}
+			}
+		},
+	}
+}
+
+// tarReader returns a tar reader for `r`, decompressing it if gzipped
+func tarReader(r io.Reader) (*tar.Reader, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(2)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		return tar.NewReader(zr), nil
+	}
+	return tar.NewReader(br), nil
+}
+
+// entryFs is a read-only filesystem containing a single tar entry
+// The contents are read from the archive into memory the first time the entry is opened so
+// entries skipped without being opened are never buffered
+type entryFs struct {
+	afero.Fs
+	mem    afero.Fs
+	hdr    *tar.Header
+	r      io.Reader
+	loaded bool
+	err    error
+}
+
+func newEntryFs(hdr *tar.Header, r io.Reader) *entryFs {
+	mem := afero.NewMemMapFs()
+	return &entryFs{Fs: afero.NewReadOnlyFs(mem), mem: mem, hdr: hdr, r: r}
+}
+
+func (e *entryFs) entry(name string) bool {
+	return filepath.Clean(name) == filepath.Clean(e.hdr.Name)
+}
+
+// load copies the entry from the archive into memory
+func (e *entryFs) load() error {
+	if e.loaded {
+		return e.err
+	}
+	e.loaded = true
+	e.err = func() error {
+		fp, err := e.mem.OpenFile(e.hdr.Name, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, e.hdr.FileInfo().Mode().Perm())
+		if err != nil {
+			return err
+		}
+		if _, err = io.Copy(fp, e.r); err != nil {
+			_ = fp.Close()
+			return err
+		}
+		if err = fp.Close(); err != nil {
+			return err
+		}
+		return e.mem.Chtimes(e.hdr.Name, e.hdr.AccessTime, e.hdr.ModTime)
+	}()
+	return e.err
+}
+
+func (e *entryFs) Open(name string) (afero.File, error) {
+	if e.entry(name) {
+		if err := e.load(); err != nil {
+			return nil, err
+		}
+	}
+	return e.Fs.Open(name)
+}
+
+func (e *entryFs) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
+	if e.entry(name) {
+		if err := e.load(); err != nil {
+			return nil, err
+		}
+	}
+	return e.Fs.OpenFile(name, flag, perm)
+}
+
+func (e *entryFs) Stat(name string) (os.FileInfo, error) {
+	if e.entry(name) && !e.loaded {
+		return e.hdr.FileInfo(), nil
+	}
+	return e.Fs.Stat(name)
+}
+
+func (e *entryFs) LstatIfPossible(name string) (os.FileInfo, bool, error) {
+	info, err := e.Stat(name)
+	return info, false, err
+}
@@ -0,0 +1,106 @@
+package archive_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bzimmer/smugmug"
+	"github.com/bzimmer/smugmug/uploadable/archive"
+	"github.com/bzimmer/smugmug/uploadable/filesystem"
+)
+
+func newTar(t *testing.T, compress bool) []byte {
+	t.Helper()
+	a := assert.New(t)
+	var buf bytes.Buffer
+	var w io.Writer = &buf
+	var zw *gzip.Writer
+	if compress {
+		zw = gzip.NewWriter(&buf)
+		w = zw
+	}
+	tw := tar.NewWriter(w)
+	a.NoError(tw.WriteHeader(&tar.Header{Name: "photos/", Typeflag: tar.TypeDir, Mode: 0o755, ModTime: modTime}))
+	for _, e := range testEntries() {
+		a.NoError(tw.WriteHeader(&tar.Header{
+			Name: e.name, Typeflag: tar.TypeReg, Mode: 0o644, Size: int64(len(e.body)), ModTime: modTime}))
+		_, err := tw.Write([]byte(e.body))
+		a.NoError(err)
+	}
+	a.NoError(tw.WriteHeader(&tar.Header{
+		Name: "photos/latest.jpg", Typeflag: tar.TypeSymlink, Linkname: "DSC0001.jpg", ModTime: modTime}))
+	a.NoError(tw.Close())
+	if zw != nil {
+		a.NoError(zw.Close())
+	}
+	return buf.Bytes()
+}
+
+func TestTarUploadables(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		compress bool
+	}{
+		{name: "tar"},
+		{name: "tar.gz", compress: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			ups, err := collect(archive.NewTarUploadables(bytes.NewReader(newTar(t, tt.compress)), newFsUploadable(t)))
+			a.NoError(err)
+			assertUploadables(a, ups)
+		})
+	}
+}
+
+func TestTarUploadablesJournaled(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	var buf bytes.Buffer
+	journal, err := smugmug.NewJournal(&buf)
+	a.NoError(err)
+	a.NoError(journal.Record(&smugmug.Upload{Uploadable: &smugmug.Uploadable{
+		Path: "photos/DSC0001.jpg", Size: 18, ModTime: modTime}}))
+
+	fsu := newFsUploadable(t)
+	fsu.Pre(filesystem.Journaled(journal))
+	ups, err := collect(archive.NewTarUploadables(bytes.NewReader(newTar(t, true)), fsu))
+	a.NoError(err)
+	a.Len(ups, 1)
+	a.Equal("photos/2024/DSC0002.JPG", ups[0].Path)
+}
+
+func TestTarUploadablesError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "truncated", data: newTar(t, false)[:1000]},
+		{name: "corrupt gzip", data: []byte{0x1f, 0x8b, 0x00}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			ups, err := collect(archive.NewTarUploadables(bytes.NewReader(tt.data), newFsUploadable(t)))
+			a.Error(err)
+			a.Empty(ups)
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package archive
+
+import (
+	"archive/zip"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/afero/zipfs"
+
+	"github.com/bzimmer/smugmug"
+	"github.com/bzimmer/smugmug/uploadable/filesystem"
+)
+
+// NewZipUploadables returns a new instance of an Uploadables which creates Uploadable instances
+// from the regular files in the zip archive
+// The entries are decompressed in memory when hashed and uploaded so `r` must remain open until
+// the uploads complete
+func NewZipUploadables(r *zip.Reader, uploadable filesystem.FsUploadable) smugmug.Uploadables {
+	afs := zipfs.New(r)
+	return &archiveUploadables{
+		uploadable: uploadable,
+		entries: func(fn func(afero.Fs, string) error) error {
+			for _, file := range r.File {
+				if !file.Mode().IsRegular() {
+					continue
+				}
+				if err := fn(afs, file.Name); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
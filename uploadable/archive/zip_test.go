@@ -0,0 +1,53 @@
+package archive_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bzimmer/smugmug/uploadable/archive"
+)
+
+func newZip(t *testing.T) *zip.Reader {
+	t.Helper()
+	a := assert.New(t)
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	_, err := zw.CreateHeader(&zip.FileHeader{Name: "photos/", Modified: modTime})
+	a.NoError(err)
+	for _, e := range testEntries() {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: e.name, Method: zip.Deflate, Modified: modTime})
+		a.NoError(err)
+		_, err = w.Write([]byte(e.body))
+		a.NoError(err)
+	}
+	a.NoError(zw.Close())
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	a.NoError(err)
+	return zr
+}
+
+func TestZipUploadables(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	ups, err := collect(archive.NewZipUploadables(newZip(t), newFsUploadable(t)))
+	a.NoError(err)
+	assertUploadables(a, ups)
+}
+
+func TestZipUploadablesCancel(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	upc, errc := archive.NewZipUploadables(newZip(t), newFsUploadable(t)).Uploadables(ctx)
+	for range upc {
+		a.Fail("unexpected uploadable")
+	}
+	a.ErrorIs(<-errc, context.Canceled)
+}
@@ -0,0 +1,201 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	tagDateTime           = 0x0132
+	tagExifIFD            = 0x8769
+	tagDateTimeOriginal   = 0x9003
+	tagOffsetTimeOriginal = 0x9011
+
+	typeASCII = 2
+	typeLong  = 4
+
+	// maxEntries bounds the number of entries read from an IFD of a malformed file
+	maxEntries = 1024
+
+	dateTimeLayout = "2006:01:02 15:04:05"
+)
+
+var (
+	// ErrNoExif indicates the file does not contain EXIF metadata
+	ErrNoExif = errors.New("exif: no exif metadata")
+	// ErrMalformed indicates the EXIF metadata could not be parsed
+	ErrMalformed = errors.New("exif: malformed metadata")
+)
+
+// Exif is the subset of EXIF metadata used for organizing uploads
+type Exif struct {
+	// DateTimeOriginal is the time the photo was taken, zero if not recorded
+	// The time is in the location of the recorded offset or time.Local if no offset was recorded
+	DateTimeOriginal time.Time
+}
+
+// Decode reads the EXIF metadata from a JPEG or a TIFF-based (eg CR2, NEF, ARW, DNG) file
+func Decode(r io.ReaderAt) (*Exif, error) {
+	header := make([]byte, 4)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, ErrNoExif
+		}
+		return nil, err
+	}
+	switch {
+	case header[0] == 0xff && header[1] == 0xd8:
+		return decodeJPEG(r)
+	case bytes.Equal(header, []byte("II*\x00")) || bytes.Equal(header, []byte("MM\x00*")):
+		return decodeTIFF(r)
+	default:
+		return nil, ErrNoExif
+	}
+}
+
+// decodeJPEG finds the APP1 segment containing the EXIF metadata
+func decodeJPEG(r io.ReaderAt) (*Exif, error) {
+	offset := int64(2)
+	marker := make([]byte, 4)
+	ident := make([]byte, 6)
+	for {
+		if _, err := r.ReadAt(marker, offset); err != nil {
+			return nil, ErrNoExif
+		}
+		if marker[0] != 0xff {
+			return nil, ErrMalformed
+		}
+		// the metadata precedes the image data
+		if marker[1] == 0xda || marker[1] == 0xd9 {
+			return nil, ErrNoExif
+		}
+		length := int64(binary.BigEndian.Uint16(marker[2:]))
+		if marker[1] == 0xe1 {
+			if _, err := r.ReadAt(ident, offset+4); err == nil && bytes.Equal(ident, []byte("Exif\x00\x00")) {
+				return decodeTIFF(io.NewSectionReader(r, offset+10, length-8))
+			}
+		}
+		offset += 2 + length
+	}
+}
+
+// tiff reads the entries of the image file directories
+type tiff struct {
+	r     io.ReaderAt
+	order binary.ByteOrder
+}
+
+// entry is a single tag of an image file directory
+type entry struct {
+	tag    uint16
+	typ    uint16
+	count  uint32
+	value  []byte
+	offset uint32
+}
+
+func decodeTIFF(r io.ReaderAt) (*Exif, error) {
+	header := make([]byte, 8)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, ErrMalformed
+	}
+	t := &tiff{r: r}
+	switch string(header[:2]) {
+	case "II":
+		t.order = binary.LittleEndian
+	case "MM":
+		t.order = binary.BigEndian
+	default:
+		return nil, ErrMalformed
+	}
+
+	ifd0, err := t.ifd(t.order.Uint32(header[4:]))
+	if err != nil {
+		return nil, err
+	}
+	x := &Exif{}
+	var datetime, offset string
+	if e, ok := ifd0[tagDateTime]; ok {
+		datetime = t.ascii(e)
+	}
+	if e, ok := ifd0[tagExifIFD]; ok && e.typ == typeLong {
+		sub, err := t.ifd(e.offset)
+		if err != nil {
+			return nil, err
+		}
+		if e, ok := sub[tagDateTimeOriginal]; ok {
+			datetime = t.ascii(e)
+		}
+		if e, ok := sub[tagOffsetTimeOriginal]; ok {
+			offset = t.ascii(e)
+		}
+	}
+	if datetime != "" {
+		x.DateTimeOriginal = parseDateTime(datetime, offset)
+	}
+	return x, nil
+}
+
+// ifd reads the entries of the image file directory at `offset`
+func (t *tiff) ifd(offset uint32) (map[uint16]*entry, error) {
+	buf := make([]byte, 2)
+	if _, err := t.r.ReadAt(buf, int64(offset)); err != nil {
+		return nil, ErrMalformed
+	}
+	n := int(t.order.Uint16(buf))
+	if n > maxEntries {
+		return nil, ErrMalformed
+	}
+	buf = make([]byte, n*12)
+	if _, err := t.r.ReadAt(buf, int64(offset)+2); err != nil {
+		return nil, ErrMalformed
+	}
+	entries := make(map[uint16]*entry, n)
+	for i := range n {
+		b := buf[i*12 : (i+1)*12]
+		e := &entry{
+			tag:    t.order.Uint16(b),
+			typ:    t.order.Uint16(b[2:]),
+			count:  t.order.Uint32(b[4:]),
+			value:  b[8:12],
+			offset: t.order.Uint32(b[8:]),
+		}
+		entries[e.tag] = e
+	}
+	return entries, nil
+}
+
+// ascii returns the value of an ASCII entry, empty if the entry is not ASCII or cannot be read
+func (t *tiff) ascii(e *entry) string {
+	if e.typ != typeASCII || e.count > maxEntries {
+		return ""
+	}
+	value := e.value[:min(e.count, 4)]
+	if e.count > 4 {
+		value = make([]byte, e.count)
+		if _, err := t.r.ReadAt(value, int64(e.offset)); err != nil {
+			return ""
+		}
+	}
+	return strings.TrimRight(string(value), "\x00 ")
+}
+
+// parseDateTime parses the EXIF date time in the location of the offset, if known, or time.Local
+func parseDateTime(datetime, offset string) time.Time {
+	loc := time.Local
+	if offset != "" {
+		if t, err := time.Parse("-07:00", offset); err == nil {
+			_, secs := t.Zone()
+			loc = time.FixedZone(offset, secs)
+		}
+	}
+	t, err := time.ParseInLocation(dateTimeLayout, datetime, loc)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
@@ -0,0 +1,101 @@
+package exif_test
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bzimmer/smugmug/uploadable/exif"
+)
+
+func TestDecode(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		filename string
+		datetime string
+		offset   int
+		err      error
+	}{
+		{
+			name:     "date time original with offset",
+			filename: "testdata/exif_gps.jpg",
+			datetime: "2024-03-04T10:11:12+01:00",
+			offset:   3600,
+		},
+		{
+			name:     "date time only",
+			filename: "testdata/datetime.jpg",
+			datetime: "2022-01-02T03:04:05",
+		},
+		{
+			name:     "tiff",
+			filename: "testdata/big_endian.tif",
+			datetime: "2023-12-31T23:59:58",
+		},
+		{
+			name:     "no exif",
+			filename: "testdata/no_exif.jpg",
+			err:      exif.ErrNoExif,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			fp, err := os.Open(tt.filename)
+			a.NoError(err)
+			defer fp.Close()
+
+			x, err := exif.Decode(fp)
+			if tt.err != nil {
+				a.ErrorIs(err, tt.err)
+				a.Nil(x)
+				return
+			}
+			a.NoError(err)
+			if tt.offset != 0 {
+				a.Equal(tt.datetime, x.DateTimeOriginal.Format(time.RFC3339))
+				_, offset := x.DateTimeOriginal.Zone()
+				a.Equal(tt.offset, offset)
+				return
+			}
+			a.Equal(time.Local, x.DateTimeOriginal.Location())
+			a.Equal(tt.datetime, x.DateTimeOriginal.Format("2006-01-02T15:04:05"))
+		})
+	}
+}
+
+func TestDecodeMalformed(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		data string
+		err  error
+	}{
+		{name: "empty", err: exif.ErrNoExif},
+		{name: "text", data: "this is a test", err: exif.ErrNoExif},
+		{name: "truncated jpeg", data: "\xff\xd8\xff\xe1\x00\x40Exif\x00\x00II*\x00", err: exif.ErrMalformed},
+		{name: "bad jpeg marker", data: "\xff\xd8\x00\x00\x00\x00", err: exif.ErrMalformed},
+		{name: "jpeg without exif", data: "\xff\xd8\xff\xe0\x00\x04JF\xff\xda\x00\x02", err: exif.ErrNoExif},
+		{name: "bad ifd offset", data: "II*\x00\xff\xff\x00\x00", err: exif.ErrMalformed},
+		{name: "too many entries", data: "II*\x00\x08\x00\x00\x00\xff\xff", err: exif.ErrMalformed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			x, err := exif.Decode(bytes.NewReader([]byte(tt.data)))
+			a.ErrorIs(err, tt.err)
+			a.Nil(x)
+		})
+	}
+}
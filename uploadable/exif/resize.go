@@ -0,0 +1,144 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+)
+
+const (
+	tagImageWidth      = 0x0100
+	tagImageLength     = 0x0101
+	tagThumbnailOffset = 0x0201
+	tagThumbnailLength = 0x0202
+	tagPixelXDimension = 0xa002
+	tagPixelYDimension = 0xa003
+
+	typeShort = 3
+)
+
+// Resized returns the metadata of the JPEG, as Metadata, for a copy of the image re-encoded at `width`
+// by `height` pixels
+// The pixel dimensions recorded in the EXIF metadata are updated and the thumbnail, which would no longer
+// match the image, is removed; the orientation and all other tags are unchanged
+func Resized(data []byte, width, height int) ([]byte, error) {
+	var out bytes.Buffer
+	if _, err := segments(data, func(marker byte, segment []byte) error {
+		switch {
+		case marker == markerAPP1 && bytes.HasPrefix(segment[4:], []byte(identExif)):
+			// the tags are updated in place so the offsets within the segment remain valid
+			segment = bytes.Clone(segment)
+			if err := resize(segment[4+len(identExif):], width, height); err != nil {
+				return err
+			}
+			out.Write(segment)
+		case marker == markerAPP1, marker == markerAPP13,
+			marker == markerAPP2 && bytes.HasPrefix(segment[4:], []byte(identICC)):
+			out.Write(segment)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// resize updates the dimensions recorded in IFD0 and the EXIF IFD of the TIFF structure and removes IFD1
+// and the thumbnail it describes
+func resize(b []byte, width, height int) error {
+	order, ok := byteOrder(b)
+	if !ok {
+		return ErrMalformed
+	}
+	ifd0 := int(order.Uint32(b[4:]))
+	if err := dimensions(b, order, ifd0, tagImageWidth, tagImageLength, width, height); err != nil {
+		return err
+	}
+	n, entries, ok := ifdEntries(b, order, ifd0)
+	if !ok {
+		return ErrMalformed
+	}
+	for i := range n {
+		e := b[entries+i*12 : entries+(i+1)*12]
+		if order.Uint16(e) != tagExifIFD {
+			continue
+		}
+		exif := int(order.Uint32(e[8:]))
+		if err := dimensions(b, order, exif, tagPixelXDimension, tagPixelYDimension, width, height); err != nil {
+			return err
+		}
+	}
+
+	next := entries + n*12
+	ifd1 := int(order.Uint32(b[next:]))
+	if ifd1 == 0 {
+		return nil
+	}
+	if err := removeThumbnail(b, order, ifd1); err != nil {
+		return err
+	}
+	clear(b[next : next+4])
+	return nil
+}
+
+// dimensions sets the values of the `x` and `y` tags of the IFD at `offset` to `width` and `height`
+func dimensions(b []byte, order binary.ByteOrder, offset int, x, y uint16, width, height int) error {
+	n, entries, ok := ifdEntries(b, order, offset)
+	if !ok {
+		return ErrMalformed
+	}
+	for i := range n {
+		e := b[entries+i*12 : entries+(i+1)*12]
+		value := -1
+		switch order.Uint16(e) {
+		case x:
+			value = width
+		case y:
+			value = height
+		}
+		if value < 0 {
+			continue
+		}
+		if order.Uint32(e[4:]) != 1 {
+			return ErrMalformed
+		}
+		switch order.Uint16(e[2:]) {
+		case typeShort:
+			if value > math.MaxUint16 {
+				return ErrMalformed
+			}
+			clear(e[8:])
+			order.PutUint16(e[8:], uint16(value)) //nolint:gosec // bounded by MaxUint16
+		case typeLong:
+			order.PutUint32(e[8:], uint32(value)) //nolint:gosec // dimensions are positive
+		default:
+			return ErrMalformed
+		}
+	}
+	return nil
+}
+
+// removeThumbnail zeroes the thumbnail described by the IFD at `offset` and the IFD itself
+func removeThumbnail(b []byte, order binary.ByteOrder, offset int) error {
+	n, entries, ok := ifdEntries(b, order, offset)
+	if !ok {
+		return ErrMalformed
+	}
+	at, size := -1, -1
+	for i := range n {
+		e := b[entries+i*12 : entries+(i+1)*12]
+		switch order.Uint16(e) {
+		case tagThumbnailOffset:
+			at = int(order.Uint32(e[8:]))
+		case tagThumbnailLength:
+			size = int(order.Uint32(e[8:]))
+		}
+	}
+	if at >= 0 && size >= 0 {
+		if at+size > len(b) {
+			return ErrMalformed
+		}
+		clear(b[at : at+size])
+	}
+	return zeroIFD(b, order, offset)
+}
@@ -0,0 +1,77 @@
+package exif_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bzimmer/smugmug/uploadable/exif"
+)
+
+// ifd returns an IFD of the entries, each a tag, type, count and value, followed by the offset of the next IFD
+func ifd(next uint32, entries ...[4]uint32) []byte {
+	order := binary.LittleEndian
+	b := order.AppendUint16(nil, uint16(len(entries))) //nolint:gosec // test fixture
+	for _, e := range entries {
+		b = order.AppendUint16(b, uint16(e[0])) //nolint:gosec // test fixture
+		b = order.AppendUint16(b, uint16(e[1])) //nolint:gosec // test fixture
+		b = order.AppendUint32(b, e[2])
+		if e[1] == 3 {
+			b = order.AppendUint16(order.AppendUint16(b, uint16(e[3])), 0) //nolint:gosec // test fixture
+			continue
+		}
+		b = order.AppendUint32(b, e[3])
+	}
+	return order.AppendUint32(b, next)
+}
+
+func TestResized(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	// IFD0 at 8 with the orientation and EXIF IFD, the EXIF IFD at 38 with the pixel dimensions and
+	// IFD1 at 68 describing the thumbnail at 98
+	thumbnail := []byte("\xff\xd8thumbnail\xff\xd9")
+	tiff := []byte("II*\x00\x08\x00\x00\x00")
+	tiff = append(tiff, ifd(68, [4]uint32{0x0112, 3, 1, 6}, [4]uint32{0x8769, 4, 1, 38})...)
+	tiff = append(tiff, ifd(0, [4]uint32{0xa002, 4, 1, 4000}, [4]uint32{0xa003, 3, 1, 3000})...)
+	tiff = append(tiff, ifd(0, [4]uint32{0x0201, 4, 1, 98}, [4]uint32{0x0202, 4, 1, uint32(len(thumbnail))})...)
+	tiff = append(tiff, thumbnail...)
+	a.Len(tiff, 98+len(thumbnail))
+
+	xmp := segment(0xe1, "http://ns.adobe.com/xap/1.0/\x00<x:xmpmeta/>")
+	data := withSegments(t, "testdata/no_exif.jpg", nil, segment(0xe1, "Exif\x00\x00"+string(tiff)), xmp)
+
+	metadata, err := exif.Resized(data, 400, 300)
+	a.NoError(err)
+	a.True(bytes.HasSuffix(metadata, xmp))
+	start := bytes.Index(metadata, []byte("Exif\x00\x00")) + 6
+	b := metadata[start : start+len(tiff)]
+
+	order := binary.LittleEndian
+	// the orientation is kept
+	a.Equal(uint16(0x0112), order.Uint16(b[10:]))
+	a.Equal(uint16(6), order.Uint16(b[18:]))
+	// the dimensions are updated
+	a.Equal(uint32(400), order.Uint32(b[48:]))
+	a.Equal(uint16(300), order.Uint16(b[60:]))
+	// the thumbnail is removed
+	a.Zero(order.Uint32(b[34:]))
+	a.Equal(make([]byte, len(tiff)-68), b[68:])
+
+	// the metadata of a JPEG without EXIF is unchanged
+	data = withSegments(t, "testdata/no_exif.jpg", nil, xmp)
+	metadata, err = exif.Resized(data, 400, 300)
+	a.NoError(err)
+	a.Equal(xmp, metadata)
+
+	// a dimension which is not a single value is malformed
+	tiff = []byte("II*\x00\x08\x00\x00\x00")
+	tiff = append(tiff, ifd(0, [4]uint32{0x0100, 4, 2, 0})...)
+	data = withSegments(t, "testdata/no_exif.jpg", nil, segment(0xe1, "Exif\x00\x00"+string(tiff)))
+	metadata, err = exif.Resized(data, 400, 300)
+	a.ErrorIs(err, exif.ErrMalformed)
+	a.Nil(metadata)
+}
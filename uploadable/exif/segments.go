@@ -0,0 +1,95 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+const (
+	markerSOI   = 0xd8
+	markerEOI   = 0xd9
+	markerSOS   = 0xda
+	markerRST0  = 0xd0
+	markerRST7  = 0xd7
+	markerAPP1  = 0xe1
+	markerAPP2  = 0xe2
+	markerAPP13 = 0xed
+
+	identExif = "Exif\x00\x00"
+	identXMP  = "http://ns.adobe.com/xap/1.0/\x00"
+	identExt  = "http://ns.adobe.com/xmp/extension/\x00"
+	identMPF  = "MPF\x00"
+	identICC  = "ICC_PROFILE\x00"
+)
+
+// Metadata returns the EXIF, XMP, IPTC and ICC profile segments of the JPEG
+// The segments may be inserted following the SOI marker of a re-encoded JPEG to retain its metadata
+func Metadata(data []byte) ([]byte, error) {
+	var out bytes.Buffer
+	if _, err := segments(data, func(marker byte, segment []byte) error {
+		switch {
+		case marker == markerAPP1, marker == markerAPP13,
+			marker == markerAPP2 && bytes.HasPrefix(segment[4:], []byte(identICC)):
+			out.Write(segment)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// segments calls `fn` with the marker and contents of each segment preceding the image data of the JPEG
+// and returns the offset of the SOS marker starting the image data
+func segments(data []byte, fn func(marker byte, segment []byte) error) (int, error) {
+	if len(data) < 2 || data[0] != 0xff || data[1] != markerSOI {
+		return 0, ErrMalformed
+	}
+	offset := 2
+	for {
+		if offset+4 > len(data) || data[offset] != 0xff {
+			return 0, ErrMalformed
+		}
+		marker := data[offset+1]
+		if marker == markerSOS {
+			return offset, nil
+		}
+		length := int(binary.BigEndian.Uint16(data[offset+2:]))
+		if length < 2 || offset+2+length > len(data) {
+			return 0, ErrMalformed
+		}
+		if err := fn(marker, data[offset:offset+2+length]); err != nil {
+			return 0, err
+		}
+		offset += 2 + length
+	}
+}
+
+// imageEnd returns the offset following the EOI marker of the image data starting with the SOS at `offset`
+func imageEnd(data []byte, offset int) (int, error) {
+	for offset+1 < len(data) {
+		marker := data[offset+1]
+		switch {
+		case marker == markerEOI:
+			return offset + 2, nil
+		case marker >= markerRST0 && marker <= markerRST7:
+			offset += 2
+		default:
+			if offset+4 > len(data) {
+				return 0, ErrMalformed
+			}
+			// skip the segment and any entropy coded data following a scan header
+			offset += 2 + int(binary.BigEndian.Uint16(data[offset+2:]))
+		}
+		if marker == markerSOS || (marker >= markerRST0 && marker <= markerRST7) {
+			// a marker in entropy coded data is a 0xff not followed by a stuffed zero
+			for offset+1 < len(data) && (data[offset] != 0xff || data[offset+1] == 0x00 || data[offset+1] == 0xff) {
+				offset++
+			}
+		}
+		if offset+1 >= len(data) || data[offset] != 0xff {
+			return 0, ErrMalformed
+		}
+	}
+	return 0, ErrMalformed
+}
@@ -0,0 +1,37 @@
+package exif_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bzimmer/smugmug/uploadable/exif"
+)
+
+func TestMetadata(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	var (
+		xmp  = segment(0xe1, "http://ns.adobe.com/xap/1.0/\x00<x:xmpmeta/>")
+		iptc = segment(0xed, "Photoshop 3.0\x008BIM")
+		icc  = segment(0xe2, "ICC_PROFILE\x00\x01\x01")
+		mpf  = segment(0xe2, "MPF\x00II*\x00")
+		jfif = segment(0xe0, "JFIF\x00\x01\x01")
+	)
+
+	data := withSegments(t, "testdata/no_exif.jpg", nil, jfif, xmp, mpf, iptc, icc)
+	metadata, err := exif.Metadata(data)
+	a.NoError(err)
+	a.Equal(bytes.Join([][]byte{xmp, iptc, icc}, nil), metadata)
+
+	data = withSegments(t, "testdata/no_exif.jpg", nil)
+	metadata, err = exif.Metadata(data)
+	a.NoError(err)
+	a.Empty(metadata)
+
+	metadata, err = exif.Metadata(data[:20])
+	a.ErrorIs(err, exif.ErrMalformed)
+	a.Nil(metadata)
+}
@@ -0,0 +1,222 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"regexp"
+)
+
+const tagGPSIFD = 0x8825
+
+// StripGPS returns a copy of the JPEG without location metadata
+// The GPS IFD is removed from the EXIF metadata and the GPS properties are removed from the XMP packet,
+// keeping its other properties such as the title and keywords
+// An XMP packet still recording a location once its GPS properties are removed, eg in a form not
+// recognized or split across extended XMP segments, is removed entirely
+// The image data is unchanged but any images following the primary image, such as MPF previews which
+// may carry their own metadata, are removed
+func StripGPS(data []byte) ([]byte, error) {
+	return strip(data, false)
+}
+
+// StripAll returns a copy of the JPEG without EXIF (including maker notes), XMP and IPTC metadata
+// The JFIF, ICC profile and Adobe segments required to correctly render the image are kept
+// The image data is unchanged but any images following the primary image are removed
+func StripAll(data []byte) ([]byte, error) {
+	return strip(data, true)
+}
+
+func strip(data []byte, all bool) ([]byte, error) {
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:min(len(data), 2)])
+	sos, err := segments(data, func(marker byte, segment []byte) error {
+		payload := segment[4:]
+		switch {
+		case marker == markerAPP1 && bytes.HasPrefix(payload, []byte(identExif)):
+			if all {
+				return nil
+			}
+			// the gps ifd is removed in place so the offsets within the segment remain valid
+			segment = bytes.Clone(segment)
+			if err := removeGPS(segment[4+len(identExif):]); err != nil {
+				return err
+			}
+		case marker == markerAPP1 && bytes.HasPrefix(payload, []byte(identXMP)):
+			if all {
+				return nil
+			}
+			if located(payload) {
+				packet := removeXMPGPS(payload[len(identXMP):])
+				if packet == nil || located(packet) {
+					return nil
+				}
+				segment = xmpSegment(packet)
+			}
+		case marker == markerAPP1 && bytes.HasPrefix(payload, []byte(identExt)):
+			if all || located(payload) {
+				return nil
+			}
+		case marker == markerAPP1 || marker == markerAPP13:
+			if all {
+				return nil
+			}
+		case marker == markerAPP2 && bytes.HasPrefix(payload, []byte(identMPF)):
+			// the images described by the multi-picture format segment are removed
+			return nil
+		}
+		out.Write(segment)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	end, err := imageEnd(data, sos)
+	if err != nil {
+		return nil, err
+	}
+	out.Write(data[sos:end])
+	return out.Bytes(), nil
+}
+
+// located returns true if the XMP records a location
+func located(xmp []byte) bool {
+	return bytes.Contains(xmp, []byte("GPSLatitude")) || bytes.Contains(xmp, []byte("GPSLongitude"))
+}
+
+var (
+	xmpGPSAttr    = regexp.MustCompile(`\s+[\w-]+:GPS\w+\s*=\s*("[^"]*"|'[^']*')`)
+	xmpGPSElement = regexp.MustCompile(`<([\w-]+:GPS\w+)[^>]*?(/>|>)`)
+)
+
+// removeXMPGPS returns the XMP packet without its GPS properties, written either as attributes or
+// elements, or nil if an element is not closed
+func removeXMPGPS(packet []byte) []byte {
+	packet = xmpGPSAttr.ReplaceAll(packet, nil)
+	out := make([]byte, 0, len(packet))
+	for {
+		loc := xmpGPSElement.FindSubmatchIndex(packet)
+		if loc == nil {
+			return append(out, packet...)
+		}
+		out = append(out, packet[:loc[0]]...)
+		end := loc[1]
+		if string(packet[loc[4]:loc[5]]) == ">" {
+			closing := []byte("</" + string(packet[loc[2]:loc[3]]) + ">")
+			i := bytes.Index(packet[end:], closing)
+			if i < 0 {
+				return nil
+			}
+			end += i + len(closing)
+		}
+		packet = packet[end:]
+	}
+}
+
+// xmpSegment returns an APP1 segment of the XMP packet
+func xmpSegment(packet []byte) []byte {
+	segment := []byte{0xff, markerAPP1, 0, 0}
+	length := 2 + len(identXMP) + len(packet)
+	binary.BigEndian.PutUint16(segment[2:], uint16(length)) //nolint:gosec // shorter than the original segment
+	segment = append(segment, identXMP...)
+	return append(segment, packet...)
+}
+
+// removeGPS zeroes the GPS IFD and its values and removes its entry from IFD0 of the TIFF structure
+func removeGPS(b []byte) error {
+	order, ok := byteOrder(b)
+	if !ok {
+		return ErrMalformed
+	}
+
+	ifd0 := int(order.Uint32(b[4:]))
+	n, entries, ok := ifdEntries(b, order, ifd0)
+	if !ok {
+		return ErrMalformed
+	}
+	index := -1
+	for i := range n {
+		if order.Uint16(b[entries+i*12:]) == tagGPSIFD {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return nil
+	}
+
+	gps := int(order.Uint32(b[entries+index*12+8:]))
+	if err := zeroIFD(b, order, gps); err != nil {
+		return err
+	}
+
+	// shift the following entries and the offset of the next ifd over the removed entry
+	start := entries + index*12
+	end := entries + n*12 + 4
+	copy(b[start:], b[start+12:end])
+	clear(b[end-12 : end])
+	order.PutUint16(b[ifd0:], uint16(n-1)) //nolint:gosec // n is bounded by maxEntries
+	return nil
+}
+
+// zeroIFD zeroes the entries of the IFD at `offset` and the values they reference
+func zeroIFD(b []byte, order binary.ByteOrder, offset int) error {
+	n, entries, ok := ifdEntries(b, order, offset)
+	if !ok {
+		return ErrMalformed
+	}
+	for i := range n {
+		e := b[entries+i*12 : entries+(i+1)*12]
+		size := typeSize(order.Uint16(e[2:])) * int(order.Uint32(e[4:]))
+		if size > 4 { //nolint:mnd // values of four bytes or less are stored in the entry
+			at := int(order.Uint32(e[8:]))
+			if at+size > len(b) {
+				return ErrMalformed
+			}
+			clear(b[at : at+size])
+		}
+	}
+	clear(b[offset : entries+n*12+4])
+	return nil
+}
+
+// byteOrder returns the byte order of the TIFF structure
+func byteOrder(b []byte) (binary.ByteOrder, bool) {
+	if len(b) < 8 { //nolint:mnd // tiff header
+		return nil, false
+	}
+	switch string(b[:2]) {
+	case "II":
+		return binary.LittleEndian, true
+	case "MM":
+		return binary.BigEndian, true
+	default:
+		return nil, false
+	}
+}
+
+// ifdEntries returns the number of entries of the IFD at `offset` and the offset of the first entry
+func ifdEntries(b []byte, order binary.ByteOrder, offset int) (int, int, bool) {
+	if offset < 0 || offset+2 > len(b) {
+		return 0, 0, false
+	}
+	n := int(order.Uint16(b[offset:]))
+	entries := offset + 2
+	if n > maxEntries || entries+n*12+4 > len(b) {
+		return 0, 0, false
+	}
+	return n, entries, true
+}
+
+// typeSize returns the size in bytes of a single value of the TIFF type
+func typeSize(typ uint16) int {
+	switch typ {
+	case 3, 8: //nolint:mnd // SHORT, SSHORT
+		return 2
+	case 4, 9, 11: //nolint:mnd // LONG, SLONG, FLOAT
+		return 4
+	case 5, 10, 12: //nolint:mnd // RATIONAL, SRATIONAL, DOUBLE
+		return 8
+	default:
+		return 1
+	}
+}
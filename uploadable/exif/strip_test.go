@@ -0,0 +1,153 @@
+package exif_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image/jpeg"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bzimmer/smugmug/uploadable/exif"
+)
+
+// segment returns a JPEG marker segment with the payload
+func segment(marker byte, payload string) []byte {
+	b := []byte{0xff, marker, 0, 0}
+	binary.BigEndian.PutUint16(b[2:], uint16(len(payload)+2))
+	return append(b, payload...)
+}
+
+// withSegments inserts the segments following the SOI of the JPEG and appends the trailer
+func withSegments(t *testing.T, filename string, trailer []byte, segments ...[]byte) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filename)
+	assert.NoError(t, err)
+	b := bytes.Clone(data[:2])
+	for _, seg := range segments {
+		b = append(b, seg...)
+	}
+	b = append(b, data[2:]...)
+	return append(b, trailer...)
+}
+
+// scan returns the image data from the first SOS through the EOI
+func scan(data []byte) []byte {
+	return data[bytes.Index(data, []byte{0xff, 0xda}) : bytes.LastIndex(data, []byte{0xff, 0xd9})+2]
+}
+
+func TestStrip(t *testing.T) {
+	t.Parallel()
+
+	var (
+		xmpGPS = segment(0xe1, "http://ns.adobe.com/xap/1.0/\x00<x:xmpmeta dc:title=\"Hike\" exif:GPSLatitude=\"47,36N\">"+
+			"<exif:GPSLongitude>122,19W</exif:GPSLongitude><exif:GPSVersionID/></x:xmpmeta>")
+		// the xmp packet without its gps properties
+		xmpHike = segment(0xe1, "http://ns.adobe.com/xap/1.0/\x00<x:xmpmeta dc:title=\"Hike\"></x:xmpmeta>")
+		// a location in a form not recognized removes the packet
+		xmpOpaque   = segment(0xe1, "http://ns.adobe.com/xap/1.0/\x00<x:xmpmeta><rdf:li>GPSLatitude</rdf:li></x:xmpmeta>")
+		xmpUnclosed = segment(0xe1, "http://ns.adobe.com/xap/1.0/\x00<x:xmpmeta><exif:GPSLatitude>47,36N</x:xmpmeta>")
+		xmp         = segment(0xe1, "http://ns.adobe.com/xap/1.0/\x00<x:xmpmeta dc:title=\"Marmot\"/>")
+		iptc        = segment(0xed, "Photoshop 3.0\x008BIM")
+		icc         = segment(0xe2, "ICC_PROFILE\x00\x01\x01")
+		mpf         = segment(0xe2, "MPF\x00II*\x00")
+		trailer     = []byte{0xff, 0xd8, 0xff, 0xd9}
+		// the rational 2280/100 of the GPS latitude seconds
+		seconds = []byte{0xe8, 0x08, 0x00, 0x00, 0x64, 0x00, 0x00, 0x00}
+	)
+
+	data := withSegments(t, "testdata/exif_gps.jpg", trailer, xmpGPS, xmpOpaque, xmpUnclosed, xmp, iptc, icc, mpf)
+	original := bytes.Clone(data)
+
+	tests := []struct {
+		name    string
+		strip   func([]byte) ([]byte, error)
+		kept    [][]byte
+		removed [][]byte
+		exif    bool
+	}{
+		{
+			name:    "gps",
+			strip:   exif.StripGPS,
+			kept:    [][]byte{xmpHike, xmp, iptc, icc, []byte("FUJIFILM")},
+			removed: [][]byte{xmpGPS, xmpOpaque, xmpUnclosed, mpf, seconds, {0x25, 0x88, 0x04, 0x00}, []byte("GPSL")},
+			exif:    true,
+		},
+		{
+			name:    "all",
+			strip:   exif.StripAll,
+			kept:    [][]byte{icc},
+			removed: [][]byte{xmpGPS, xmpHike, xmpOpaque, xmp, iptc, mpf, seconds, []byte("Exif\x00\x00")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			out, err := tt.strip(data)
+			a.NoError(err)
+			a.Equal(original, data)
+			for _, b := range tt.kept {
+				a.True(bytes.Contains(out, b))
+			}
+			for _, b := range tt.removed {
+				a.False(bytes.Contains(out, b))
+			}
+
+			// the image data is unchanged and the images following the primary image are removed
+			a.Equal(scan(original[:len(original)-len(trailer)]), scan(out))
+			a.True(bytes.HasSuffix(out, scan(out)))
+			_, err = jpeg.Decode(bytes.NewReader(out))
+			a.NoError(err)
+
+			x, err := exif.Decode(bytes.NewReader(out))
+			if !tt.exif {
+				a.ErrorIs(err, exif.ErrNoExif)
+				return
+			}
+			a.NoError(err)
+			a.Equal("2024-03-04T10:11:12+01:00", x.DateTimeOriginal.Format(time.RFC3339))
+		})
+	}
+}
+
+func TestStripNoGPS(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	for _, filename := range []string{"testdata/datetime.jpg", "testdata/no_exif.jpg"} {
+		data, err := os.ReadFile(filename)
+		a.NoError(err)
+		out, err := exif.StripGPS(data)
+		a.NoError(err)
+		a.Equal(data, out)
+	}
+}
+
+func TestStripMalformed(t *testing.T) {
+	t.Parallel()
+
+	data, err := os.ReadFile("testdata/exif_gps.jpg")
+	assert.NoError(t, err)
+
+	for name, b := range map[string][]byte{
+		"empty":     nil,
+		"tiff":      []byte("II*\x00\x08\x00\x00\x00"),
+		"truncated": data[:len(data)/2],
+		"no eoi":    data[:len(data)-2],
+		"segment":   append([]byte{0xff, 0xd8, 0xff, 0xe0, 0xff, 0xff}, data[2:]...),
+		"gps ifd":   withSegments(t, "testdata/no_exif.jpg", nil, segment(0xe1, "Exif\x00\x00II*\x00\x08\x00\x00\x00\x01\x00")),
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			out, err := exif.StripGPS(b)
+			a.ErrorIs(err, exif.ErrMalformed)
+			a.Nil(out)
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package filesystem
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/bzimmer/smugmug"
+	"github.com/bzimmer/smugmug/uploadable/exif"
+)
+
+// templateRE matches the date placeholders of an album path template
+var templateRE = regexp.MustCompile(`\{([^{}]*)\}`)
+
+// expand replaces the placeholders in the template with the components of the date
+func expand(template string, t time.Time) string {
+	replacer := strings.NewReplacer(
+		"month", t.Format("January"),
+		"mon", t.Format("Jan"),
+		"yyyy", t.Format("2006"),
+		"yy", t.Format("06"),
+		"mm", t.Format("01"),
+		"dd", t.Format("02"),
+	)
+	return templateRE.ReplaceAllStringFunc(template, func(s string) string {
+		return replacer.Replace(s[1 : len(s)-1])
+	})
+}
+
+// NewDateUploadable returns an FsUploadable which uploads each file into the album at the path created by
+// expanding `template` with the date the file was taken, creating the folders and album if necessary
+// Missing folders and albums are created only when a file is uploaded
+// The date is the EXIF DateTimeOriginal, or the modification time of the file if not recorded
+// Placeholders are enclosed in braces and may combine `yyyy`, `yy`, `mm`, `dd`, `month` and `mon`,
+// eg `/Photos/{yyyy}/{yyyy-mm-dd}`
+func NewDateUploadable(ctx context.Context, resolver *AlbumResolver, template string) (FsUploadable, error) {
+	if len(split(template)) == 0 {
+		return nil, errors.New("missing template")
+	}
+	for _, m := range templateRE.FindAllStringSubmatch(template, -1) {
+		if expand(m[0], time.Time{}) == m[1] {
+			return nil, fmt.Errorf("unknown placeholder `%s` in template `%s`", m[0], template)
+		}
+	}
+	return &fsUploadable{album: func(fs afero.Fs, up *smugmug.Uploadable) (string, error) {
+		t, err := dateTaken(fs, up)
+		if err != nil {
+			return "", err
+		}
+		return resolver.album(ctx, up, expand(template, t))
+	}}, nil
+}
+
+// dateTaken returns the EXIF DateTimeOriginal of the file or its modification time if not recorded
+func dateTaken(fs afero.Fs, up *smugmug.Uploadable) (time.Time, error) {
+	fp, err := fs.Open(up.Path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer fp.Close()
+	x, err := exif.Decode(fp)
+	switch {
+	case errors.Is(err, exif.ErrNoExif), errors.Is(err, exif.ErrMalformed):
+		return up.ModTime, nil
+	case err != nil:
+		return time.Time{}, err
+	case x.DateTimeOriginal.IsZero():
+		return up.ModTime, nil
+	default:
+		return x.DateTimeOriginal, nil
+	}
+}
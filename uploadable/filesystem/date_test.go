@@ -0,0 +1,119 @@
+package filesystem_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bzimmer/smugmug"
+	"github.com/bzimmer/smugmug/uploadable/filesystem"
+)
+
+func TestNewDateUploadable(t *testing.T) {
+	t.Parallel()
+
+	for _, template := range []string{"", "/", "/Photos/{yyyy}/{unknown}"} {
+		t.Run(template, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			fsu, err := filesystem.NewDateUploadable(context.TODO(), nil, template)
+			a.Error(err)
+			a.Nil(fsu)
+		})
+	}
+}
+
+func TestDateUploadable(t *testing.T) {
+	t.Parallel()
+
+	modTime := time.Date(2023, time.July, 8, 9, 10, 11, 0, time.Local)
+
+	tests := []struct {
+		name     string
+		filename string
+		template string
+		albumKey string
+		created  []string
+		err      bool
+	}{
+		{
+			name:     "exif date",
+			filename: "exif_gps.jpg",
+			template: "/Photos/{yyyy}/{yyyy-mm-dd}",
+			albumKey: "c1",
+			created:  []string{"Folder:2024:2024:Unlisted", "Album:2024-03-04:2024-03-04:Unlisted"},
+		},
+		{
+			name:     "modification time",
+			filename: "no_exif.jpg",
+			template: "/Photos/{yyyy}/{yyyy-mm-dd}",
+			albumKey: "c1",
+			created:  []string{"Folder:2023:2023:Unlisted", "Album:2023-07-08:2023-07-08:Unlisted"},
+		},
+		{
+			name:     "month names",
+			filename: "exif_gps.jpg",
+			template: "/{month} {yy}/{dd} {mon}",
+			albumKey: "c1",
+			created:  []string{"Folder:March 24:March-24:Unlisted", "Album:04 Mar:04-Mar:Unlisted"},
+		},
+		{
+			name:     "existing album",
+			filename: "exif_gps.jpg",
+			template: "/Misc",
+			albumKey: "a2",
+		},
+		{
+			name:     "failed to create",
+			filename: "exif_gps.jpg",
+			template: "/Photos/fail",
+			err:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			n := newNodes()
+			svr := n.server(t)
+			defer svr.Close()
+
+			mg, err := smugmug.NewClient(smugmug.WithBaseURL(svr.URL))
+			a.NoError(err)
+
+			data, err := os.ReadFile("testdata/" + tt.filename)
+			a.NoError(err)
+			fs := new(afero.MemMapFs)
+			a.NoError(afero.WriteFile(fs, "DCIM/"+tt.filename, data, 0644))
+			a.NoError(fs.Chtimes("DCIM/"+tt.filename, modTime, modTime))
+
+			fsu, err := filesystem.NewDateUploadable(
+				context.TODO(), filesystem.NewAlbumResolver(mg, ""), tt.template)
+			a.NoError(err)
+
+			up, err := fsu.Uploadable(fs, "DCIM/"+tt.filename)
+			a.NoError(err)
+			a.NotEmpty(up.AlbumPath)
+			// missing folders and albums are created only when uploaded
+			a.Empty(n.created)
+			if up.AlbumKey == "" {
+				a.NotNil(up.CreateAlbum)
+				up.AlbumKey, err = up.CreateAlbum(context.TODO())
+				if tt.err {
+					a.Error(err)
+					a.Empty(up.AlbumKey)
+					return
+				}
+				a.NoError(err)
+			}
+			a.Equal(tt.albumKey, up.AlbumKey)
+			a.Equal(tt.created, n.created)
+		})
+	}
+}
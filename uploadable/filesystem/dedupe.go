@@ -0,0 +1,57 @@
+package filesystem
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/bzimmer/smugmug"
+)
+
+// DuplicateFunc is called with an Uploadable and the path, or image uri, of the file it duplicates
+type DuplicateFunc func(up *smugmug.Uploadable, original string)
+
+// Dedupe detects Uploadables with the same MD5 as an earlier Uploadable in the batch or an image in `images`
+// If `skip` is true a duplicate is not uploaded; if `fn` is not nil it is called for each duplicate
+// A new UseFunc should be created for each batch as the MD5s seen are retained
+func Dedupe(skip bool, images ImageLookup, fn DuplicateFunc) UseFunc {
+	var mu sync.Mutex
+	seen := make(map[string]string)
+	if images != nil {
+		for _, img := range images.Images() {
+			if img.ArchivedMD5 == "" {
+				continue
+			}
+			original := img.FileName
+			if img.URIs.Image != nil {
+				original = img.URIs.Image.URI
+			}
+			seen[strings.ToLower(img.ArchivedMD5)] = original
+		}
+	}
+	return func(up *smugmug.Uploadable) error {
+		if up.MD5 == "" {
+			return nil
+		}
+		mu.Lock()
+		md5 := strings.ToLower(up.MD5)
+		original, ok := seen[md5]
+		if !ok {
+			seen[md5] = up.Path
+			if up.Path == "" {
+				seen[md5] = up.Name
+			}
+		}
+		mu.Unlock()
+		if !ok {
+			return nil
+		}
+		if fn != nil {
+			fn(up, original)
+		}
+		if skip {
+			return fmt.Errorf("%w: `%s` duplicates `%s`", ErrSkip, up.Name, original)
+		}
+		return nil
+	}
+}
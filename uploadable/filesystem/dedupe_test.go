@@ -0,0 +1,103 @@
+package filesystem_test
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bzimmer/smugmug"
+	"github.com/bzimmer/smugmug/uploadable/filesystem"
+)
+
+func TestDedupe(t *testing.T) {
+	t.Parallel()
+
+	images := filesystem.Images{
+		"DSC0004.jpg": {
+			FileName:    "DSC0004.jpg",
+			ArchivedMD5: "0CC175B9C0F1B6A831C399E269772661",
+			URIs:        smugmug.ImageURIs{Image: &smugmug.APIEndpoint{URI: "/api/v2/image/Lm3TqR8-0"}},
+		},
+		"DSC0005.jpg": {FileName: "DSC0005.jpg", ArchivedMD5: "92eb5ffee6ae2fec3ad71c777531578f"},
+		"DSC0006.jpg": {FileName: "DSC0006.jpg"},
+	}
+
+	tests := []struct {
+		name       string
+		skip       bool
+		images     filesystem.Images
+		uploaded   []string
+		duplicates map[string]string
+	}{
+		{
+			name:     "skip batch duplicates",
+			skip:     true,
+			uploaded: []string{"a/DSC0001.jpg", "a/DSC0003.jpg", "b/DSC0004.jpg"},
+			duplicates: map[string]string{
+				"b/IMG0001.jpg": "a/DSC0001.jpg",
+				"c/DSC0001.jpg": "a/DSC0001.jpg",
+			},
+		},
+		{
+			name:     "skip album duplicates",
+			skip:     true,
+			images:   images,
+			uploaded: []string{"a/DSC0001.jpg", "a/DSC0003.jpg"},
+			duplicates: map[string]string{
+				"b/IMG0001.jpg": "a/DSC0001.jpg",
+				"c/DSC0001.jpg": "a/DSC0001.jpg",
+				"b/DSC0004.jpg": "/api/v2/image/Lm3TqR8-0",
+			},
+		},
+		{
+			name:   "report only",
+			images: images,
+			uploaded: []string{
+				"a/DSC0001.jpg", "a/DSC0003.jpg", "b/IMG0001.jpg", "b/DSC0004.jpg", "c/DSC0001.jpg"},
+			duplicates: map[string]string{
+				"b/IMG0001.jpg": "a/DSC0001.jpg",
+				"c/DSC0001.jpg": "a/DSC0001.jpg",
+				"b/DSC0004.jpg": "/api/v2/image/Lm3TqR8-0",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			fs := new(afero.MemMapFs)
+			files := [][2]string{
+				{"a/DSC0001.jpg", "this is a test"},
+				{"a/DSC0003.jpg", "this is another test"},
+				{"b/IMG0001.jpg", "this is a test"},
+				{"b/DSC0004.jpg", "a"},
+				{"c/DSC0001.jpg", "this is a test"},
+			}
+			for _, f := range files {
+				a.NoError(afero.WriteFile(fs, f[0], []byte(f[1]), 0644))
+			}
+
+			duplicates := make(map[string]string)
+			fsup, err := filesystem.NewFsUploadable("albumKey")
+			a.NoError(err)
+			fsup.Use(filesystem.Dedupe(tt.skip, tt.images, func(up *smugmug.Uploadable, original string) {
+				duplicates[up.Path] = original
+			}))
+
+			var uploaded []string
+			for _, f := range files {
+				up, err := fsup.Uploadable(fs, f[0])
+				if err != nil {
+					a.ErrorIs(err, filesystem.ErrSkip)
+					continue
+				}
+				uploaded = append(uploaded, up.Path)
+			}
+			a.Equal(tt.uploaded, uploaded)
+			a.Equal(tt.duplicates, duplicates)
+		})
+	}
+}
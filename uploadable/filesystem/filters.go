@@ -0,0 +1,132 @@
+package filesystem
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// globs compiles the patterns to regular expressions matching the trailing components of a path
+func globs(patterns []string) ([]*regexp.Regexp, error) {
+	res := make([]*regexp.Regexp, len(patterns))
+	for i := range patterns {
+		re, err := globRegexp(strings.TrimPrefix(patterns[i], "/"), false)
+		if err != nil {
+			return nil, err
+		}
+		res[i] = re
+	}
+	return res, nil
+}
+
+// matches returns true if any of the regular expressions match the slash separated filename
+func matches(res []*regexp.Regexp, filename string) bool {
+	filename = filepath.ToSlash(filename)
+	for i := range res {
+		if res[i].MatchString(filename) {
+			return true
+		}
+	}
+	return false
+}
+
+// Include accepts only files matching at least one of the glob patterns
+// The patterns use gitignore syntax and match the trailing components of the path, eg `*.jpg` or `2024/**/*.jpg`
+func Include(patterns ...string) PreFunc {
+	res, err := globs(patterns)
+	return func(_ afero.Fs, filename string) (bool, error) {
+		if err != nil {
+			return false, err
+		}
+		return matches(res, filename), nil
+	}
+}
+
+// Exclude rejects files matching any of the glob patterns
+// The patterns use gitignore syntax and match the trailing components of the path, eg `*.tmp` or `drafts/**`
+func Exclude(patterns ...string) PreFunc {
+	res, err := globs(patterns)
+	return func(_ afero.Fs, filename string) (bool, error) {
+		if err != nil {
+			return false, err
+		}
+		return !matches(res, filename), nil
+	}
+}
+
+// IncludeRegexp accepts only files whose slash separated path matches at least one of the regular expressions
+func IncludeRegexp(res ...*regexp.Regexp) PreFunc {
+	return func(_ afero.Fs, filename string) (bool, error) {
+		return matches(res, filename), nil
+	}
+}
+
+// ExcludeRegexp rejects files whose slash separated path matches any of the regular expressions
+func ExcludeRegexp(res ...*regexp.Regexp) PreFunc {
+	return func(_ afero.Fs, filename string) (bool, error) {
+		return !matches(res, filename), nil
+	}
+}
+
+// MinSize accepts only files of at least `size` bytes
+func MinSize(size int64) PreFunc {
+	return func(fs afero.Fs, filename string) (bool, error) {
+		info, err := fs.Stat(filename)
+		if err != nil {
+			return false, err
+		}
+		return info.Size() >= size, nil
+	}
+}
+
+// MaxSize accepts only files of at most `size` bytes
+func MaxSize(size int64) PreFunc {
+	return func(fs afero.Fs, filename string) (bool, error) {
+		info, err := fs.Stat(filename)
+		if err != nil {
+			return false, err
+		}
+		return info.Size() <= size, nil
+	}
+}
+
+// ModifiedBetween accepts only files modified at or after `after` and before `before`
+// A zero time does not bound the window
+func ModifiedBetween(after, before time.Time) PreFunc {
+	return func(fs afero.Fs, filename string) (bool, error) {
+		info, err := fs.Stat(filename)
+		if err != nil {
+			return false, err
+		}
+		modTime := info.ModTime()
+		if !after.IsZero() && modTime.Before(after) {
+			return false, nil
+		}
+		if !before.IsZero() && !modTime.Before(before) {
+			return false, nil
+		}
+		return true, nil
+	}
+}
+
+// SkipHidden rejects files whose name begins with a dot
+func SkipHidden() PreFunc {
+	return func(_ afero.Fs, filename string) (bool, error) {
+		return !strings.HasPrefix(filepath.Base(filename), "."), nil
+	}
+}
+
+// SkipDotDirs rejects files in a directory whose name begins with a dot, eg `.git` or `.thumbnails`
+func SkipDotDirs() PreFunc {
+	return func(_ afero.Fs, filename string) (bool, error) {
+		for dir := range strings.SplitSeq(filepath.ToSlash(filepath.Dir(filename)), "/") {
+			if dir != "." && dir != ".." && strings.HasPrefix(dir, ".") {
+				return false, nil
+			}
+		}
+		return true, nil
+	}
+}
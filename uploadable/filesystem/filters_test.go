@@ -0,0 +1,82 @@
+package filesystem_test
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bzimmer/smugmug/uploadable/filesystem"
+)
+
+func TestFilters(t *testing.T) {
+	t.Parallel()
+
+	modTime := time.Date(2024, time.March, 4, 10, 11, 12, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		pre      filesystem.PreFunc
+		filename string
+		ok       bool
+		err      bool
+	}{
+		{name: "include extension", pre: filesystem.Include("*.jpg", "*.png"), filename: "photos/DSC0001.jpg", ok: true},
+		{name: "include no match", pre: filesystem.Include("*.png"), filename: "photos/DSC0001.jpg"},
+		{name: "include directory", pre: filesystem.Include("photos/*.jpg"), filename: "2024/photos/DSC0001.jpg",
+			ok: true},
+		{name: "include double star", pre: filesystem.Include("2024/**/*.jpg"), filename: "2024/03/04/DSC0001.jpg",
+			ok: true},
+		{name: "include class", pre: filesystem.Include("DSC[0-9]*.jpg"), filename: "DSC0001.jpg", ok: true},
+		{name: "include negated class", pre: filesystem.Include("DSC[!0-9]*.jpg"), filename: "DSC0001.jpg"},
+		{name: "include bad pattern", pre: filesystem.Include("[z-a]"), filename: "DSC0001.jpg", err: true},
+		{name: "exclude", pre: filesystem.Exclude("drafts/**"), filename: "photos/drafts/DSC0001.jpg"},
+		{name: "exclude no match", pre: filesystem.Exclude("drafts/**"), filename: "photos/DSC0001.jpg", ok: true},
+		{name: "exclude single character", pre: filesystem.Exclude("DSC000?.jpg"), filename: "DSC0001.jpg"},
+		{name: "exclude bad pattern", pre: filesystem.Exclude("[z-a]"), filename: "DSC0001.jpg", err: true},
+		{name: "include regexp", pre: filesystem.IncludeRegexp(regexp.MustCompile(`(?i)\.jpe?g$`)),
+			filename: "DSC0001.JPEG", ok: true},
+		{name: "exclude regexp", pre: filesystem.ExcludeRegexp(regexp.MustCompile(`/edits/`)),
+			filename: "photos/edits/DSC0001.jpg"},
+		{name: "min size", pre: filesystem.MinSize(14), filename: "DSC0001.jpg", ok: true},
+		{name: "min size too small", pre: filesystem.MinSize(15), filename: "DSC0001.jpg"},
+		{name: "min size missing", pre: filesystem.MinSize(15), filename: "missing.jpg", err: true},
+		{name: "max size", pre: filesystem.MaxSize(14), filename: "DSC0001.jpg", ok: true},
+		{name: "max size too large", pre: filesystem.MaxSize(13), filename: "DSC0001.jpg"},
+		{name: "max size missing", pre: filesystem.MaxSize(13), filename: "missing.jpg", err: true},
+		{name: "modified between", pre: filesystem.ModifiedBetween(modTime, modTime.Add(time.Hour)),
+			filename: "DSC0001.jpg", ok: true},
+		{name: "modified after", pre: filesystem.ModifiedBetween(modTime.Add(time.Second), time.Time{}),
+			filename: "DSC0001.jpg"},
+		{name: "modified before", pre: filesystem.ModifiedBetween(time.Time{}, modTime), filename: "DSC0001.jpg"},
+		{name: "modified unbounded", pre: filesystem.ModifiedBetween(time.Time{}, time.Time{}),
+			filename: "DSC0001.jpg", ok: true},
+		{name: "modified missing", pre: filesystem.ModifiedBetween(time.Time{}, time.Time{}),
+			filename: "missing.jpg", err: true},
+		{name: "hidden", pre: filesystem.SkipHidden(), filename: "photos/.DSC0001.jpg"},
+		{name: "not hidden", pre: filesystem.SkipHidden(), filename: ".photos/DSC0001.jpg", ok: true},
+		{name: "dot dir", pre: filesystem.SkipDotDirs(), filename: "photos/.thumbnails/DSC0001.jpg"},
+		{name: "relative dir", pre: filesystem.SkipDotDirs(), filename: "../photos/DSC0001.jpg", ok: true},
+		{name: "no dot dir", pre: filesystem.SkipDotDirs(), filename: "./photos/.DSC0001.jpg", ok: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			fs := new(afero.MemMapFs)
+			a.NoError(afero.WriteFile(fs, "DSC0001.jpg", []byte("this is a test"), 0644))
+			a.NoError(fs.Chtimes("DSC0001.jpg", modTime, modTime))
+			ok, err := tt.pre(fs, tt.filename)
+			if tt.err {
+				a.Error(err)
+				a.False(ok)
+				return
+			}
+			a.NoError(err)
+			a.Equal(tt.ok, ok)
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package filesystem
+
+import (
+	"errors"
+
+	"github.com/spf13/afero"
+
+	"github.com/bzimmer/smugmug"
+	"github.com/bzimmer/smugmug/uploadable/exif"
+	"github.com/bzimmer/smugmug/uploadable/gpx"
+)
+
+// Geotag sets the location of the Uploadable by matching its EXIF DateTimeOriginal to the track of the Geotagger
+// Uploadables with a location, without a capture time or taken away from the track are unchanged
+func Geotag(afs afero.Fs, geotagger *gpx.Geotagger) UseFunc {
+	return func(up *smugmug.Uploadable) error {
+		if up.Location != nil {
+			return nil
+		}
+		fp, err := afs.Open(up.Path)
+		if err != nil {
+			return err
+		}
+		defer fp.Close()
+		x, err := exif.Decode(fp)
+		switch {
+		case errors.Is(err, exif.ErrNoExif), errors.Is(err, exif.ErrMalformed):
+			return nil
+		case err != nil:
+			return err
+		case x.DateTimeOriginal.IsZero():
+			return nil
+		}
+		if pt, ok := geotagger.Locate(x.DateTimeOriginal); ok {
+			up.Location = &smugmug.Location{Latitude: pt.Latitude, Longitude: pt.Longitude, Altitude: pt.Elevation}
+		}
+		return nil
+	}
+}
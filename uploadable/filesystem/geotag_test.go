@@ -0,0 +1,80 @@
+package filesystem_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bzimmer/smugmug"
+	"github.com/bzimmer/smugmug/uploadable/filesystem"
+	"github.com/bzimmer/smugmug/uploadable/gpx"
+)
+
+func TestGeotag(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		filename string
+		offset   time.Duration
+		location *smugmug.Location
+		tagged   bool
+	}{
+		{
+			name:     "interpolated",
+			filename: "exif_gps.jpg",
+			tagged:   true,
+		},
+		{
+			name:     "camera clock ahead",
+			filename: "exif_gps.jpg",
+			offset:   time.Hour,
+		},
+		{
+			name:     "existing location",
+			filename: "exif_gps.jpg",
+			location: &smugmug.Location{Latitude: 1, Longitude: 2},
+		},
+		{
+			name:     "no exif",
+			filename: "no_exif.jpg",
+		},
+	}
+
+	track, err := gpx.Load(afero.NewOsFs(), "testdata/hike.gpx")
+	assert.NoError(t, err)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			data, err := os.ReadFile("testdata/" + tt.filename)
+			a.NoError(err)
+			fs := afero.NewMemMapFs()
+			a.NoError(afero.WriteFile(fs, tt.filename, data, 0644))
+
+			fsu, err := filesystem.NewFsUploadable("7dFHSm")
+			a.NoError(err)
+			fsu.Use(func(up *smugmug.Uploadable) error {
+				up.Location = tt.location
+				return nil
+			}, filesystem.Geotag(fs, &gpx.Geotagger{Track: track, Offset: tt.offset}))
+
+			up, err := fsu.Uploadable(fs, tt.filename)
+			a.NoError(err)
+			switch {
+			case tt.tagged:
+				a.NotNil(up.Location)
+				a.InDelta(47.606, up.Location.Latitude, 1e-9)
+				a.InDelta(-122.312, up.Location.Longitude, 1e-9)
+				a.InDelta(160, up.Location.Altitude, 1e-9)
+			default:
+				a.Equal(tt.location, up.Location)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package filesystem
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// IgnoreFile is the name of the file listing, in gitignore syntax, files not to upload
+// The patterns apply to the directory containing the file and all directories below it
+const IgnoreFile = ".smugignore"
+
+// rule is a single pattern from an ignore file
+type rule struct {
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+func (r *rule) match(name string, dir bool) bool {
+	if r.dirOnly && !dir {
+		return false
+	}
+	return r.re.MatchString(name)
+}
+
+// parseIgnore parses the rules of an ignore file in gitignore syntax
+func parseIgnore(data []byte) ([]*rule, error) {
+	var rules []*rule
+	for line := range strings.Lines(string(data)) {
+		line = strings.TrimRight(line, "\r\n")
+		if !strings.HasSuffix(line, `\ `) {
+			line = strings.TrimRight(line, " ")
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		r := &rule{}
+		if strings.HasPrefix(line, "!") {
+			r.negate = true
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			r.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		// a pattern with a separator other than at the end is relative to the ignore file's directory
+		anchored := strings.Contains(line, "/")
+		re, err := globRegexp(strings.TrimPrefix(line, "/"), anchored)
+		if err != nil {
+			return nil, err
+		}
+		r.re = re
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+// globRegexp converts a gitignore glob to a regular expression matching slash separated paths
+// An unanchored pattern matches the trailing components of a path
+func globRegexp(pattern string, anchored bool) (*regexp.Regexp, error) {
+	var sb strings.Builder
+	sb.WriteString("^")
+	if !anchored {
+		sb.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		start := i == 0 || pattern[i-1] == '/'
+		switch {
+		case start && strings.HasPrefix(pattern[i:], "**/"):
+			sb.WriteString("(?:.*/)?")
+			i += 2
+		case start && pattern[i:] == "**":
+			sb.WriteString(".*")
+			i++
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				sb.WriteString(`\[`)
+				continue
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + class + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(pattern):
+			i++
+			sb.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+	return regexp.Compile(sb.String())
+}
+
+// ignore holds the rules of the ignore file in a directory
+type ignore struct {
+	parent *ignore
+	dir    string
+	rules  []*rule
+}
+
+// match returns true if the path is ignored by the rules of this directory or any of its parents
+// The last matching rule wins and rules in a directory take precedence over its parents
+func (g *ignore) match(path string, dir bool) bool {
+	var ignored bool
+	if g.parent != nil {
+		ignored = g.parent.match(path, dir)
+	}
+	rel, err := filepath.Rel(g.dir, path)
+	if err != nil {
+		return ignored
+	}
+	rel = filepath.ToSlash(rel)
+	for _, r := range g.rules {
+		if r.match(rel, dir) {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+// ignores tracks the ignore files of the directories visited during a walk
+type ignores struct {
+	read func(string) ([]byte, error)
+	dirs map[string]*ignore
+}
+
+func newIgnores(read func(string) ([]byte, error)) *ignores {
+	return &ignores{read: read, dirs: make(map[string]*ignore)}
+}
+
+// enter loads the ignore file of the directory, returning false if the directory is ignored
+func (g *ignores) enter(dir string) (bool, error) {
+	parent := g.dirs[filepath.Dir(dir)]
+	if parent != nil && parent.match(dir, true) {
+		return false, nil
+	}
+	data, err := g.read(filepath.Join(dir, IgnoreFile))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return false, err
+	}
+	rules, err := parseIgnore(data)
+	if err != nil {
+		return false, err
+	}
+	g.dirs[dir] = &ignore{parent: parent, dir: dir, rules: rules}
+	return true, nil
+}
+
+// accept returns false if the file is ignored
+func (g *ignores) accept(filename string) bool {
+	if filepath.Base(filename) == IgnoreFile {
+		return false
+	}
+	ig := g.dirs[filepath.Dir(filename)]
+	return ig == nil || !ig.match(filename, false)
+}
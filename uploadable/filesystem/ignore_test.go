@@ -0,0 +1,103 @@
+package filesystem_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bzimmer/smugmug"
+	"github.com/bzimmer/smugmug/uploadable/filesystem"
+)
+
+func uploadablePaths(uploadables smugmug.Uploadables) ([]string, error) {
+	upc, errc := uploadables.Uploadables(context.TODO())
+	var paths []string
+	for up := range upc {
+		paths = append(paths, up.Path)
+	}
+	slices.Sort(paths)
+	return paths, <-errc
+}
+
+func TestIgnoreFile(t *testing.T) {
+	t.Parallel()
+
+	files := map[string]string{
+		"photos/.smugignore": `
+# comments and blank lines are ignored
+
+*.xmp
+drafts/
+/DSC0003.jpg
+!keep.xmp
+2024/**/rejects
+`,
+		"photos/DSC0001.jpg":                 "this is a test",
+		"photos/DSC0001.xmp":                 "<xmp/>",
+		"photos/keep.xmp":                    "<xmp/>",
+		"photos/DSC0003.jpg":                 "this is a test",
+		"photos/drafts/DSC0002.jpg":          "this is a test",
+		"photos/2024/DSC0003.jpg":            "this is a test",
+		"photos/2024/03/rejects/DSC0004.jpg": "this is a test",
+		"photos/2024/.smugignore":            "!*.xmp\n*.png\n",
+		"photos/2024/DSC0004.xmp":            "<xmp/>",
+		"photos/2024/DSC0005.png":            "this is a test",
+		"DSC0006.png":                        "this is a test",
+	}
+	expected := []string{
+		"DSC0006.png",
+		"photos/2024/DSC0003.jpg",
+		"photos/2024/DSC0004.xmp",
+		"photos/DSC0001.jpg",
+		"photos/keep.xmp",
+	}
+
+	t.Run("afero", func(t *testing.T) {
+		t.Parallel()
+		a := assert.New(t)
+
+		afs := new(afero.MemMapFs)
+		for name, content := range files {
+			a.NoError(afero.WriteFile(afs, name, []byte(content), 0644))
+		}
+		fsu, err := filesystem.NewFsUploadable("7dFHSm")
+		a.NoError(err)
+		paths, err := uploadablePaths(filesystem.NewFsUploadables(afs, []string{"."}, fsu))
+		a.NoError(err)
+		a.Equal(expected, paths)
+	})
+
+	t.Run("iofs", func(t *testing.T) {
+		t.Parallel()
+		a := assert.New(t)
+
+		fsys := fstest.MapFS{}
+		for name, content := range files {
+			fsys[name] = &fstest.MapFile{Data: []byte(content)}
+		}
+		fsu, err := filesystem.NewFsUploadable("7dFHSm")
+		a.NoError(err)
+		paths, err := uploadablePaths(filesystem.NewIOFSUploadables(fsys, []string{"."}, fsu, false))
+		a.NoError(err)
+		a.Equal(expected, paths)
+	})
+}
+
+func TestIgnoreFileError(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	fsys := fstest.MapFS{
+		"photos/.smugignore": {Data: []byte("[z-a]\n")},
+		"photos/DSC0001.jpg": {Data: []byte("this is a test")},
+	}
+	fsu, err := filesystem.NewFsUploadable("7dFHSm")
+	a.NoError(err)
+	paths, err := uploadablePaths(filesystem.NewIOFSUploadables(fsys, []string{"."}, fsu, false))
+	a.Error(err)
+	a.Empty(paths)
+}
@@ -0,0 +1,133 @@
+package filesystem
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/bzimmer/smugmug"
+)
+
+// ImageLookup finds the images of an album by file name
+// Both Images and *Index implement ImageLookup, eg for `SkipIndex` and `ReplaceIndex`
+type ImageLookup interface {
+	// Image returns the image with the file name `name`
+	Image(name string) (*smugmug.Image, bool)
+	// Images returns all the images
+	Images() []*smugmug.Image
+}
+
+// Images maps the images of an album by file name
+type Images map[string]*smugmug.Image
+
+// Image returns the image with the file name `name`
+func (x Images) Image(name string) (*smugmug.Image, bool) {
+	img, ok := x[name]
+	return img, ok
+}
+
+// Images returns the images sorted by file name
+func (x Images) Images() []*smugmug.Image {
+	images := make([]*smugmug.Image, 0, len(x))
+	for _, img := range x {
+		images = append(images, img)
+	}
+	sort.Slice(images, func(i, j int) bool {
+		return images[i].FileName < images[j].FileName
+	})
+	return images
+}
+
+// Index finds the images of an album by file name, by normalized file name and by MD5
+type Index struct {
+	images     []*smugmug.Image
+	names      map[string]*smugmug.Image
+	normalized map[string]*smugmug.Image
+	md5s       map[string]*smugmug.Image
+}
+
+// NewIndex returns an Index of the images
+func NewIndex(images ...*smugmug.Image) *Index {
+	index := &Index{
+		names:      make(map[string]*smugmug.Image, len(images)),
+		normalized: make(map[string]*smugmug.Image, len(images)),
+		md5s:       make(map[string]*smugmug.Image, len(images)),
+	}
+	for _, img := range images {
+		if _, ok := index.names[img.FileName]; !ok {
+			index.names[img.FileName] = img
+		}
+		if key := normalize(img.FileName); index.normalized[key] == nil {
+			index.normalized[key] = img
+		}
+		if img.ArchivedMD5 != "" {
+			if key := strings.ToLower(img.ArchivedMD5); index.md5s[key] == nil {
+				index.md5s[key] = img
+			}
+		}
+	}
+	index.images = append(index.images, images...)
+	sort.SliceStable(index.images, func(i, j int) bool {
+		return index.images[i].FileName < index.images[j].FileName
+	})
+	return index
+}
+
+// LoadIndex returns an Index of the images in the album `albumKey`
+// Only the file name, MD5 and image uri of each image are queried
+func LoadIndex(ctx context.Context, client *smugmug.Client, albumKey string) (*Index, error) {
+	var images []*smugmug.Image
+	if err := client.Image.ImagesIter(ctx, albumKey, func(img *smugmug.Image) (bool, error) {
+		images = append(images, img)
+		return true, nil
+	}, smugmug.WithFilters("FileName", "ArchivedMD5", "Uri"), smugmug.WithFilterURIs("Image")); err != nil {
+		return nil, err
+	}
+	return NewIndex(images...), nil
+}
+
+// normalize returns the NFC normalized, case folded file name
+// macOS filesystems report names in NFD so names which appear identical may not be byte equal
+func normalize(name string) string {
+	return cases.Fold().String(norm.NFC.String(name))
+}
+
+// Image returns the image with the file name `name`, matching the normalized name if no exact match exists
+func (x *Index) Image(name string) (*smugmug.Image, bool) {
+	if x == nil {
+		return nil, false
+	}
+	if img, ok := x.names[name]; ok {
+		return img, true
+	}
+	img, ok := x.normalized[normalize(name)]
+	return img, ok
+}
+
+// MD5 returns the image with the archived MD5 `md5`
+func (x *Index) MD5(md5 string) (*smugmug.Image, bool) {
+	if x == nil {
+		return nil, false
+	}
+	img, ok := x.md5s[strings.ToLower(md5)]
+	return img, ok
+}
+
+// Images returns the images in the index sorted by file name
+func (x *Index) Images() []*smugmug.Image {
+	if x == nil {
+		return nil
+	}
+	return x.images
+}
+
+// Len returns the number of images in the index
+func (x *Index) Len() int {
+	if x == nil {
+		return 0
+	}
+	return len(x.images)
+}
@@ -0,0 +1,129 @@
+package filesystem_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bzimmer/smugmug"
+	"github.com/bzimmer/smugmug/uploadable/filesystem"
+)
+
+func TestIndex(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	cafe := &smugmug.Image{
+		FileName:    "Cafe\u0301.jpg", // NFD as written by macOS
+		ArchivedMD5: "54b0c58c7ce9f2a8b551351102ee0938",
+		URIs:        smugmug.ImageURIs{Image: &smugmug.APIEndpoint{URI: "/api/v2/image/nB6kCv2-0"}},
+	}
+	upper := &smugmug.Image{
+		FileName:    "DSC0001.JPG",
+		ArchivedMD5: "d41d8cd98f00b204e9800998ecf8427e",
+		URIs:        smugmug.ImageURIs{Image: &smugmug.APIEndpoint{URI: "/api/v2/image/Xw9HjP4-0"}},
+	}
+	lower := &smugmug.Image{
+		FileName: "dsc0001.jpg",
+		URIs:     smugmug.ImageURIs{Image: &smugmug.APIEndpoint{URI: "/api/v2/image/Lm3TqR8-0"}},
+	}
+	index := filesystem.NewIndex(cafe, upper, lower)
+
+	img, ok := index.Image("Caf\u00e9.JPG")
+	a.True(ok)
+	a.Equal(cafe, img)
+	img, ok = index.Image("dsc0001.jpg")
+	a.True(ok)
+	a.Equal(lower, img)
+	img, ok = index.Image("Dsc0001.Jpg")
+	a.True(ok)
+	a.Equal(upper, img)
+	_, ok = index.Image("DSC0002.jpg")
+	a.False(ok)
+
+	img, ok = index.MD5("54B0C58C7CE9F2A8B551351102EE0938")
+	a.True(ok)
+	a.Equal(cafe, img)
+	_, ok = index.MD5("0cc175b9c0f1b6a831c399e269772661")
+	a.False(ok)
+
+	a.Equal([]*smugmug.Image{cafe, upper, lower}, index.Images())
+	a.Equal(3, index.Len())
+
+	fs := new(afero.MemMapFs)
+	a.NoError(afero.WriteFile(fs, "Caf\u00e9.JPG", []byte("this is a test"), 0644))
+	a.NoError(afero.WriteFile(fs, "Dsc0001.Jpg", []byte("this is a test"), 0644))
+
+	fsup, err := filesystem.NewFsUploadable("albumKey")
+	a.NoError(err)
+	fsup.Use(filesystem.SkipIndex(false, index), filesystem.ReplaceIndex(true, index))
+	up, err := fsup.Uploadable(fs, "Caf\u00e9.JPG")
+	a.ErrorIs(err, filesystem.ErrSkip)
+	a.Nil(up)
+	up, err = fsup.Uploadable(fs, "Dsc0001.Jpg")
+	a.NoError(err)
+	a.Equal("/api/v2/image/Xw9HjP4-0", up.Replaces)
+}
+
+func TestImages(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	img := &smugmug.Image{FileName: "DSC0001.jpg", ArchivedMD5: "54b0c58c7ce9f2a8b551351102ee0938"}
+	images := filesystem.Images{"DSC0001.jpg": img}
+	found, ok := images.Image("DSC0001.jpg")
+	a.True(ok)
+	a.Equal(img, found)
+	// a map of images matches exact names only
+	_, ok = images.Image("dsc0001.JPG")
+	a.False(ok)
+	a.Equal([]*smugmug.Image{img}, images.Images())
+
+	// a nil Index finds nothing
+	var index *filesystem.Index
+	_, ok = index.Image("DSC0001.jpg")
+	a.False(ok)
+	_, ok = index.MD5(img.ArchivedMD5)
+	a.False(ok)
+	a.Empty(index.Images())
+	a.Zero(index.Len())
+}
+
+func TestLoadIndex(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/album/QpLn7s!images", func(w http.ResponseWriter, r *http.Request) {
+		a.Equal("FileName,ArchivedMD5,Uri", r.URL.Query().Get("_filter"))
+		a.Equal("Image", r.URL.Query().Get("_filteruri"))
+		http.ServeFile(w, r, "testdata/album_images_QpLn7s.json")
+	})
+	mux.HandleFunc("/album/missing!images", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	svr := httptest.NewServer(mux)
+	defer svr.Close()
+
+	mg, err := smugmug.NewClient(smugmug.WithBaseURL(svr.URL))
+	a.NoError(err)
+
+	index, err := filesystem.LoadIndex(context.TODO(), mg, "QpLn7s")
+	a.NoError(err)
+	a.Len(index.Images(), 3)
+	img, ok := index.Image("dsc0004.JPG")
+	a.True(ok)
+	a.Equal("/api/v2/image/Lm3TqR8-0", img.URIs.Image.URI)
+	img, ok = index.MD5("54b0c58c7ce9f2a8b551351102ee0938")
+	a.True(ok)
+	a.Equal("DSC0001.jpg", img.FileName)
+	a.Equal(3, index.Len())
+
+	index, err = filesystem.LoadIndex(context.TODO(), mg, "missing")
+	a.Error(err)
+	a.Nil(index)
+}
@@ -0,0 +1,122 @@
+package filesystem
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/spf13/afero"
+
+	"github.com/bzimmer/smugmug"
+)
+
+// maxSymlinks is the number of symlinks followed resolving a path before it is considered a loop
+const maxSymlinks = 40
+
+// ErrSymlinkLoop indicates a symlink refers to a directory containing it
+var ErrSymlinkLoop = errors.New("symlink loop")
+
+// NewIOFSUploadables returns a new instance of an Uploadables which creates Uploadable instances
+// from files in the io/fs filesystem
+// If `follow` is true symlinks are followed if the filesystem implements fs.ReadLinkFS, otherwise they are ignored
+func NewIOFSUploadables(fsys fs.FS, roots []string, uploadable FsUploadable, follow bool) smugmug.Uploadables {
+	return &fsUploadables{
+		fs:         &afero.FromIOFS{FS: fsys},
+		filenames:  roots,
+		uploadable: uploadable,
+		walker:     iofsWalker(fsys, follow),
+	}
+}
+
+// iofsWalker walks the io/fs filesystem skipping files matched by an IgnoreFile
+func iofsWalker(fsys fs.FS, follow bool) walker {
+	var walk func(root string, visited []string, ignores *ignores, fn func(string) error) error
+	walk = func(root string, visited []string, ignores *ignores, fn func(string) error) error {
+		return fs.WalkDir(fsys, root, func(name string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			switch {
+			case d.IsDir():
+				ok, err := ignores.enter(name)
+				if err != nil {
+					return err
+				}
+				if !ok {
+					return fs.SkipDir
+				}
+				return nil
+			case !ignores.accept(name):
+				return nil
+			case d.Type()&fs.ModeSymlink != 0:
+				if !follow {
+					return nil
+				}
+				target, err := realpath(fsys, name)
+				if err != nil {
+					return err
+				}
+				info, err := fs.Stat(fsys, target)
+				if err != nil {
+					return err
+				}
+				if !info.IsDir() {
+					return fn(name)
+				}
+				parent, err := realpath(fsys, path.Dir(name))
+				if err != nil {
+					return err
+				}
+				if contains(target, parent) || slices.Contains(visited, target) {
+					return fmt.Errorf("%w: `%s` refers to `%s`", ErrSymlinkLoop, name, target)
+				}
+				return walk(name, append(visited, target), ignores, fn)
+			default:
+				return fn(name)
+			}
+		})
+	}
+	return func(root string, fn func(string) error) error {
+		ignores := newIgnores(func(name string) ([]byte, error) { return fs.ReadFile(fsys, name) })
+		return walk(root, nil, ignores, fn)
+	}
+}
+
+// contains returns true if the directory `dir` is, or is an ancestor of, `name`
+func contains(dir, name string) bool {
+	return dir == "." || dir == name || strings.HasPrefix(name, dir+"/")
+}
+
+// realpath returns `name` with all symlinks resolved
+func realpath(fsys fs.FS, name string) (string, error) {
+	resolved, remaining, links := ".", path.Clean(name), 0
+	for remaining != "." {
+		head, tail, _ := strings.Cut(remaining, "/")
+		remaining = path.Clean(tail)
+		next := path.Join(resolved, head)
+		info, err := fs.Lstat(fsys, next)
+		if err != nil {
+			return "", err
+		}
+		if info.Mode()&fs.ModeSymlink == 0 {
+			resolved = next
+			continue
+		}
+		if links++; links > maxSymlinks {
+			return "", fmt.Errorf("%w: too many links resolving `%s`", ErrSymlinkLoop, name)
+		}
+		link, err := fs.ReadLink(fsys, next)
+		if err != nil {
+			return "", err
+		}
+		target := path.Join(resolved, link, remaining)
+		if path.IsAbs(link) || target == ".." || strings.HasPrefix(target, "../") {
+			return "", fmt.Errorf("symlink `%s` refers outside the filesystem", next)
+		}
+		resolved, remaining = ".", target
+	}
+	return resolved, nil
+}
@@ -0,0 +1,140 @@
+package filesystem_test
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bzimmer/smugmug"
+	"github.com/bzimmer/smugmug/uploadable/filesystem"
+)
+
+func iofsPaths(fsys fs.FS, follow bool) ([]string, error) {
+	fsu, err := filesystem.NewFsUploadable("7dFHSm")
+	if err != nil {
+		return nil, err
+	}
+	fsu.Pre(filesystem.Extensions(".jpg"))
+	return uploadablePaths(filesystem.NewIOFSUploadables(fsys, []string{"."}, fsu, follow))
+}
+
+func symlink(target string) *fstest.MapFile {
+	return &fstest.MapFile{Data: []byte(target), Mode: fs.ModeSymlink}
+}
+
+func TestIOFSUploadables(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"photos/DSC0001.jpg":      {Data: []byte("this is a test")},
+		"photos/2024/DSC0002.jpg": {Data: []byte("this is another test")},
+		"photos/Readme.md":        {Data: []byte("bah")},
+		"links/DSC0003.jpg":       symlink("../photos/DSC0001.jpg"),
+		"links/2024":              symlink("../photos/2024"),
+	}
+
+	tests := []struct {
+		name   string
+		fsys   fs.FS
+		follow bool
+		paths  []string
+		err    error
+	}{
+		{
+			name:  "ignore symlinks",
+			fsys:  fsys,
+			paths: []string{"photos/2024/DSC0002.jpg", "photos/DSC0001.jpg"},
+		},
+		{
+			name:   "follow symlinks",
+			fsys:   fsys,
+			follow: true,
+			paths: []string{
+				"links/2024/DSC0002.jpg", "links/DSC0003.jpg", "photos/2024/DSC0002.jpg", "photos/DSC0001.jpg"},
+		},
+		{
+			name: "loop to parent",
+			fsys: fstest.MapFS{
+				"photos/DSC0001.jpg": {Data: []byte("this is a test")},
+				"photos/all":         symlink(".."),
+			},
+			follow: true,
+			err:    filesystem.ErrSymlinkLoop,
+		},
+		{
+			name: "loop between directories",
+			fsys: fstest.MapFS{
+				"a/DSC0001.jpg": {Data: []byte("this is a test")},
+				"a/b":           symlink("../c"),
+				"c/a":           symlink("../a"),
+			},
+			follow: true,
+			err:    filesystem.ErrSymlinkLoop,
+		},
+		{
+			name: "link to itself",
+			fsys: fstest.MapFS{
+				"photos/DSC0001.jpg": {Data: []byte("this is a test")},
+				"photos/self":        symlink("self"),
+			},
+			follow: true,
+			err:    filesystem.ErrSymlinkLoop,
+		},
+		{
+			name: "broken link",
+			fsys: fstest.MapFS{
+				"photos/DSC0001.jpg": {Data: []byte("this is a test")},
+				"photos/missing":     symlink("missing.jpg"),
+			},
+			follow: true,
+			err:    fs.ErrNotExist,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			paths, err := iofsPaths(tt.fsys, tt.follow)
+			if tt.err != nil {
+				a.ErrorIs(err, tt.err)
+				return
+			}
+			a.NoError(err)
+			a.Equal(tt.paths, paths)
+		})
+	}
+}
+
+func TestIOFSUploadablesDirFS(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	root := t.TempDir()
+	a.NoError(os.MkdirAll(filepath.Join(root, "photos"), 0o755))
+	a.NoError(os.WriteFile(filepath.Join(root, "photos", "DSC0001.jpg"), []byte("this is a test"), 0o600))
+	a.NoError(os.Symlink("photos", filepath.Join(root, "latest")))
+
+	paths, err := iofsPaths(os.DirFS(root), true)
+	a.NoError(err)
+	a.Equal([]string{"latest/DSC0001.jpg", "photos/DSC0001.jpg"}, paths)
+
+	fsu, err := filesystem.NewFsUploadable("7dFHSm")
+	a.NoError(err)
+	upc, errc := filesystem.NewIOFSUploadables(
+		os.DirFS(root), []string{"photos"}, fsu, false).Uploadables(context.TODO())
+	var ups []*smugmug.Uploadable
+	for up := range upc {
+		ups = append(ups, up)
+	}
+	a.NoError(<-errc)
+	a.Len(ups, 1)
+	a.Equal("54b0c58c7ce9f2a8b551351102ee0938", ups[0].MD5)
+	a.Equal(int64(14), ups[0].Size)
+}
@@ -0,0 +1,120 @@
+package filesystem
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/spf13/afero"
+
+	"github.com/bzimmer/smugmug"
+)
+
+// mirror maps the directories under a root to albums of the same path
+type mirror struct {
+	root     string
+	resolver *AlbumResolver
+	mu       sync.Mutex
+	leaves   map[string]bool
+	ignores  *ignores
+}
+
+// NewMirrorUploadable returns an FsUploadable which recreates the directory hierarchy under `root` as
+// folders and albums, creating them if necessary when a file is uploaded
+// Leaf directories become albums and all other directories become folders; a file in a directory with
+// subdirectories, including `root` itself, or outside `root` cannot be uploaded and is skipped
+// Dot directories and directories excluded by an IgnoreFile are not counted as subdirectories
+// Use NewNodeAlbumResolver to recreate the hierarchy under a node other than the user's root node
+func NewMirrorUploadable(ctx context.Context, resolver *AlbumResolver, root string) (FsUploadable, error) {
+	if resolver == nil {
+		return nil, errors.New("missing resolver")
+	}
+	m := &mirror{root: filepath.Clean(root), resolver: resolver, leaves: make(map[string]bool)}
+	return &fsUploadable{album: func(fs afero.Fs, up *smugmug.Uploadable) (string, error) {
+		albumPath, err := m.albumPath(fs, up.Path)
+		if err != nil {
+			return "", err
+		}
+		return m.resolver.album(ctx, up, albumPath)
+	}}, nil
+}
+
+// albumPath returns the path of the album for the directory of `filename` relative to the root
+func (m *mirror) albumPath(fs afero.Fs, filename string) (string, error) {
+	dir := filepath.Dir(filepath.Clean(filename))
+	rel, err := filepath.Rel(m.root, dir)
+	if err != nil {
+		return "", err
+	}
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: file `%s` is not under `%s`", ErrSkip, filename, m.root)
+	}
+	if rel == "." {
+		return "", fmt.Errorf("%w: file `%s` is not in a subdirectory of `%s`", ErrSkip, filename, m.root)
+	}
+	leaf, err := m.leaf(fs, rel)
+	if err != nil {
+		return "", err
+	}
+	if !leaf {
+		return "", fmt.Errorf(
+			"%w: file `%s` is in a directory with subdirectories and cannot be uploaded to an album", ErrSkip, filename)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// leaf returns true if the directory at `rel`, relative to the root, has no subdirectories which could
+// hold files to upload
+func (m *mirror) leaf(fs afero.Fs, rel string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	dir := filepath.Join(m.root, rel)
+	if leaf, ok := m.leaves[dir]; ok {
+		return leaf, nil
+	}
+	ignore, err := m.ignore(fs, rel)
+	if err != nil {
+		return false, err
+	}
+	infos, err := afero.ReadDir(fs, dir)
+	if err != nil {
+		return false, err
+	}
+	leaf := true
+	for _, info := range infos {
+		if !info.IsDir() || strings.HasPrefix(info.Name(), ".") {
+			continue
+		}
+		if ignore != nil && ignore.match(filepath.Join(dir, info.Name()), true) {
+			continue
+		}
+		leaf = false
+		break
+	}
+	m.leaves[dir] = leaf
+	return leaf, nil
+}
+
+// ignore returns the ignore rules of the directory at `rel`, relative to the root, reading the
+// IgnoreFile of each directory from the root down; nil is returned if the directory is itself ignored
+func (m *mirror) ignore(fs afero.Fs, rel string) (*ignore, error) {
+	if m.ignores == nil {
+		m.ignores = newIgnores(func(name string) ([]byte, error) { return afero.ReadFile(fs, name) })
+	}
+	dir := m.root
+	names := append([]string{"."}, strings.Split(rel, string(filepath.Separator))...)
+	for _, name := range names {
+		dir = filepath.Join(dir, name)
+		if _, ok := m.ignores.dirs[dir]; ok {
+			continue
+		}
+		ok, err := m.ignores.enter(dir)
+		if err != nil || !ok {
+			return nil, err
+		}
+	}
+	return m.ignores.dirs[dir], nil
+}
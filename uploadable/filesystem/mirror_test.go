@@ -0,0 +1,191 @@
+package filesystem_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bzimmer/smugmug"
+	"github.com/bzimmer/smugmug/uploadable/filesystem"
+)
+
+func TestNewMirrorUploadable(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	fsu, err := filesystem.NewMirrorUploadable(context.TODO(), nil, "/archive")
+	a.Error(err)
+	a.Nil(fsu)
+}
+
+func TestMirrorUploadables(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	n := newNodes()
+	svr := n.server(t)
+	defer svr.Close()
+
+	mg, err := smugmug.NewClient(
+		smugmug.WithBaseURL(svr.URL), smugmug.WithUploadURL(svr.URL), smugmug.WithConcurrency(1))
+	a.NoError(err)
+
+	fs := afero.NewMemMapFs()
+	for _, filename := range []string{
+		"/archive/2023/Drafts/notes.txt",
+		"/archive/2023/DSC0000.jpg",
+		"/archive/DSC0000.jpg",
+		"/archive/2023/Summer Trip/DSC0001.jpg",
+		"/archive/2023/Summer Trip/DSC0002.jpg",
+		"/archive/2023/Winter/DSC0003.jpg",
+		"/archive/Misc/DSC0004.jpg",
+	} {
+		a.NoError(afero.WriteFile(fs, filename, []byte(filename), 0644))
+	}
+
+	fsu, err := filesystem.NewMirrorUploadable(
+		context.TODO(), filesystem.NewNodeAlbumResolver(mg, "n1", "Private"), "/archive/")
+	a.NoError(err)
+	fsu.Pre(filesystem.Extensions(".jpg"))
+	uploadables := filesystem.NewFsUploadables(fs, []string{"/archive"}, fsu)
+
+	// nothing is created until uploaded
+	upc, errc := uploadables.Uploadables(context.TODO())
+	for up := range upc {
+		a.Empty(up.AlbumKey)
+	}
+	a.NoError(<-errc)
+	a.Empty(n.created)
+
+	summary, err := smugmug.Summarize(mg.Upload.Uploads(context.TODO(), uploadables))
+	a.NoError(err)
+	// files in directories which are not albums are skipped rather than stopping the batch
+	a.Empty(summary.Failed)
+	a.Len(summary.Succeeded, 4)
+	albums := make(map[string]string)
+	for _, upload := range summary.Succeeded {
+		albums[upload.Uploadable.Name] = upload.Uploadable.AlbumKey
+	}
+	a.Equal(map[string]string{
+		"DSC0001.jpg": "c1", "DSC0002.jpg": "c1", "DSC0003.jpg": "c2", "DSC0004.jpg": "c3"}, albums)
+	a.Equal([]string{
+		"Folder:2023:2023:Private",
+		"Album:Summer Trip:Summer-Trip:Private",
+		"Album:Winter:Winter:Private",
+		"Album:Misc:Misc:Private",
+	}, n.created)
+}
+
+func TestMirrorUploadable(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		filename string
+		dirs     []string
+		ignore   map[string]string
+		albumKey string
+		err      string
+	}{
+		{
+			name:     "existing album",
+			filename: "/archive/Misc/DSC0001.jpg",
+			albumKey: "a2",
+		},
+		{
+			name:     "root",
+			filename: "/archive/DSC0001.jpg",
+			err:      "not in a subdirectory",
+		},
+		{
+			name:     "not a leaf",
+			filename: "/archive/Photos/DSC0001.jpg",
+			err:      "directory with subdirectories",
+		},
+		{
+			name:     "outside root",
+			filename: "/other/DSC0001.jpg",
+			err:      "is not under",
+		},
+		{
+			name:     "existing folder",
+			filename: "/archive/Photos/2024/DSC0001.jpg",
+			albumKey: "c0",
+		},
+		{
+			name:     "dot directory",
+			filename: "/archive/Misc/DSC0001.jpg",
+			dirs:     []string{"/archive/Misc/.thumbnails"},
+			albumKey: "a2",
+		},
+		{
+			name:     "ignored directory",
+			filename: "/archive/Misc/DSC0001.jpg",
+			dirs:     []string{"/archive/Misc/drafts"},
+			ignore:   map[string]string{"/archive/.smugignore": "drafts/\n"},
+			albumKey: "a2",
+		},
+		{
+			name:     "ignored directory in a subdirectory",
+			filename: "/archive/Misc/DSC0001.jpg",
+			dirs:     []string{"/archive/Misc/drafts"},
+			ignore:   map[string]string{"/archive/Misc/.smugignore": "/drafts\n"},
+			albumKey: "a2",
+		},
+		{
+			name:     "negated ignored directory",
+			filename: "/archive/Misc/DSC0001.jpg",
+			dirs:     []string{"/archive/Misc/drafts"},
+			ignore: map[string]string{
+				"/archive/.smugignore":      "drafts/\n",
+				"/archive/Misc/.smugignore": "!drafts/\n",
+			},
+			err: "directory with subdirectories",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			n := newNodes()
+			svr := n.server(t)
+			defer svr.Close()
+
+			mg, err := smugmug.NewClient(smugmug.WithBaseURL(svr.URL))
+			a.NoError(err)
+
+			fs := afero.NewMemMapFs()
+			a.NoError(fs.MkdirAll("/archive/Photos/2024", 0755))
+			a.NoError(afero.WriteFile(fs, tt.filename, []byte(tt.filename), 0644))
+			for _, dir := range tt.dirs {
+				a.NoError(fs.MkdirAll(dir, 0755))
+			}
+			for filename, data := range tt.ignore {
+				a.NoError(afero.WriteFile(fs, filename, []byte(data), 0644))
+			}
+
+			fsu, err := filesystem.NewMirrorUploadable(
+				context.TODO(), filesystem.NewAlbumResolver(mg, ""), "/archive")
+			a.NoError(err)
+
+			up, err := fsu.Uploadable(fs, tt.filename)
+			if tt.err != "" {
+				a.ErrorIs(err, filesystem.ErrSkip)
+				a.ErrorContains(err, tt.err)
+				a.Nil(up)
+				return
+			}
+			a.NoError(err)
+			a.Empty(n.created)
+			if up.AlbumKey == "" {
+				up.AlbumKey, err = up.CreateAlbum(context.TODO())
+				a.NoError(err)
+			}
+			a.Equal(tt.albumKey, up.AlbumKey)
+		})
+	}
+}
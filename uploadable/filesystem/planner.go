@@ -0,0 +1,187 @@
+package filesystem
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/spf13/afero"
+
+	"github.com/bzimmer/smugmug"
+)
+
+// Plan is the set of actions required to synchronize local files with an album
+type Plan struct {
+	// AlbumKey is the album being synchronized
+	AlbumKey string
+	// Uploads are local files not found in the album
+	Uploads []*smugmug.Uploadable
+	// Replacements are local files found in the album with a different MD5
+	Replacements []*smugmug.Uploadable
+	// Unchanged are local files found in the album with the same MD5
+	Unchanged []*smugmug.Uploadable
+	// Skipped are local files skipped by a PreFunc or UseFunc
+	Skipped []*smugmug.Uploadable
+	// RemoteOnly are images in the album with no matching local file
+	RemoteOnly []*smugmug.Image
+}
+
+// Size returns the number of bytes of the new and replacement Uploadables in the plan
+func (p *Plan) Size() int64 {
+	var size int64
+	for _, ups := range [][]*smugmug.Uploadable{p.Uploads, p.Replacements} {
+		for _, up := range ups {
+			size += up.Size
+		}
+	}
+	return size
+}
+
+// Uploadables returns a channel of the new, replacement and skipped Uploadables in the plan
+func (p *Plan) Uploadables(ctx context.Context) (<-chan *smugmug.Uploadable, <-chan error) {
+	errc := make(chan error, 1)
+	uploadablesc := make(chan *smugmug.Uploadable)
+	go func() {
+		defer close(errc)
+		defer close(uploadablesc)
+		for _, ups := range [][]*smugmug.Uploadable{p.Uploads, p.Replacements, p.Skipped} {
+			for _, up := range ups {
+				select {
+				case <-ctx.Done():
+					errc <- ctx.Err()
+					return
+				case uploadablesc <- up:
+				}
+			}
+		}
+	}()
+	return uploadablesc, errc
+}
+
+// Write writes a human-readable description of the plan (eg for a dry run)
+func (p *Plan) Write(w io.Writer) error {
+	lines := []struct {
+		action string
+		ups    []*smugmug.Uploadable
+	}{
+		{"upload", p.Uploads},
+		{"replace", p.Replacements},
+		{"unchanged", p.Unchanged},
+		{"skip", p.Skipped},
+	}
+	for _, line := range lines {
+		for _, up := range line.ups {
+			if _, err := fmt.Fprintf(w, "%-9s %s (%d bytes)\n", line.action, up.Name, up.Size); err != nil {
+				return err
+			}
+		}
+	}
+	for _, img := range p.RemoteOnly {
+		if _, err := fmt.Fprintf(w, "%-9s %s (%s)\n", "remote", img.FileName, img.ImageKey); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "album %s: %d upload, %d replace, %d unchanged, %d skip, %d remote\n",
+		p.AlbumKey, len(p.Uploads), len(p.Replacements), len(p.Unchanged), len(p.Skipped), len(p.RemoteOnly))
+	return err
+}
+
+// Planner compares files on a filesystem with the images in an album
+type Planner struct {
+	client     *smugmug.Client
+	fs         afero.Fs
+	uploadable FsUploadable
+}
+
+// NewPlanner returns a new Planner which creates Uploadables from `afs` using `uploadable`
+func NewPlanner(client *smugmug.Client, afs afero.Fs, uploadable FsUploadable) *Planner {
+	return &Planner{client: client, fs: afs, uploadable: uploadable}
+}
+
+// Plan compares the files found under `filenames` with the images in the album `albumKey`
+func (p *Planner) Plan(ctx context.Context, albumKey string, filenames []string) (*Plan, error) {
+	images := make(map[string]*smugmug.Image)
+	if err := p.client.Image.ImagesIter(ctx, albumKey, func(img *smugmug.Image) (bool, error) {
+		images[img.FileName] = img
+		return true, nil
+	}); err != nil {
+		return nil, err
+	}
+	return p.plan(ctx, albumKey, filenames, images)
+}
+
+func (p *Planner) plan(
+	ctx context.Context, albumKey string, filenames []string, images map[string]*smugmug.Image) (*Plan, error) {
+	plan := &Plan{AlbumKey: albumKey}
+	seen := make(map[string]bool)
+	// skipped files are reported so their images are not considered remote only
+	fsu := &fsUploadables{
+		fs: p.fs, filenames: filenames, uploadable: p.uploadable, walker: aferoWalker(p.fs), skipped: true}
+	uploadablesc, errc := fsu.Uploadables(ctx)
+	for up := range uploadablesc {
+		// the plan is computed against `albumKey` so the upload must target the same album
+		up.AlbumKey = albumKey
+		img, ok := images[up.Name]
+		switch {
+		case up.Skipped != nil:
+			seen[up.Name] = true
+			plan.Skipped = append(plan.Skipped, up)
+		case !ok:
+			plan.Uploads = append(plan.Uploads, up)
+		case up.MD5 == img.ArchivedMD5:
+			seen[up.Name] = true
+			plan.Unchanged = append(plan.Unchanged, up)
+		default:
+			seen[up.Name] = true
+			if img.URIs.Image != nil {
+				up.Replaces = img.URIs.Image.URI
+			}
+			plan.Replacements = append(plan.Replacements, up)
+		}
+	}
+	if err := <-errc; err != nil {
+		return nil, err
+	}
+	for name, img := range images {
+		if !seen[name] {
+			plan.RemoteOnly = append(plan.RemoteOnly, img)
+		}
+	}
+	sort.Slice(plan.RemoteOnly, func(i, j int) bool {
+		return plan.RemoteOnly[i].FileName < plan.RemoteOnly[j].FileName
+	})
+	return plan, nil
+}
+
+// Execute uploads the new and replacement files in the plan and, if `prune` is true,
+// deletes the images found only in the album
+// Pruning is skipped with an error if any upload failed (eg when configured with `WithContinueOnError`)
+// Skipped uploads are not failures
+func (p *Planner) Execute(ctx context.Context, plan *Plan, prune bool) ([]*smugmug.Upload, error) {
+	var failed int
+	var uploads []*smugmug.Upload
+	uploadc, errc := p.client.Upload.Uploads(ctx, plan)
+	for upload := range uploadc {
+		if upload.Err != nil && !errors.Is(upload.Err, smugmug.ErrSkip) {
+			failed++
+		}
+		uploads = append(uploads, upload)
+	}
+	if err := <-errc; err != nil {
+		return uploads, err
+	}
+	if !prune {
+		return uploads, nil
+	}
+	if failed > 0 {
+		return uploads, fmt.Errorf("not pruning album `%s`: %d upload(s) failed", plan.AlbumKey, failed)
+	}
+	for _, img := range plan.RemoteOnly {
+		if _, err := p.client.Image.Delete(ctx, plan.AlbumKey, img.ImageKey); err != nil {
+			return uploads, err
+		}
+	}
+	return uploads, nil
+}
@@ -0,0 +1,177 @@
+package filesystem_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bzimmer/smugmug"
+	"github.com/bzimmer/smugmug/uploadable/filesystem"
+)
+
+func TestPlanner(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	var uploads, deletes atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/album/QpLn7s!images", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "testdata/album_images_QpLn7s.json")
+	})
+	mux.HandleFunc("/album/QpLn7s/image/{imageKey}", func(w http.ResponseWriter, r *http.Request) {
+		a.Equal(http.MethodDelete, r.Method)
+		a.Equal("Lm3TqR8", r.PathValue("imageKey"))
+		deletes.Add(1)
+		http.ServeFile(w, r, "testdata/image_743XwH7_delete.json")
+	})
+	mux.HandleFunc("/{$}", func(w http.ResponseWriter, r *http.Request) {
+		uploads.Add(1)
+		if r.Header.Get("X-Smug-FileName") == "DSC0002.jpg" {
+			a.Equal("/api/v2/image/Xw9HjP4-0", r.Header.Get("X-Smug-ImageUri"))
+		}
+		http.ServeFile(w, r, "testdata/upload_CVvj69L.json")
+	})
+	svr := httptest.NewServer(mux)
+	defer svr.Close()
+
+	mg, err := smugmug.NewClient(smugmug.WithBaseURL(svr.URL), smugmug.WithUploadURL(svr.URL))
+	a.NoError(err)
+
+	fs := new(afero.MemMapFs)
+	for _, filename := range []string{"DSC0001.jpg", "DSC0002.jpg", "DSC0003.jpg"} {
+		a.NoError(afero.WriteFile(fs, filename, []byte("this is a test"), 0644))
+	}
+	fsup, err := filesystem.NewFsUploadable("QpLn7s")
+	a.NoError(err)
+
+	planner := filesystem.NewPlanner(mg, fs, fsup)
+	plan, err := planner.Plan(context.TODO(), "QpLn7s", []string{"DSC0001.jpg", "DSC0002.jpg", "DSC0003.jpg"})
+	a.NoError(err)
+	a.NotNil(plan)
+	a.Len(plan.Uploads, 1)
+	a.Equal("DSC0003.jpg", plan.Uploads[0].Name)
+	a.Len(plan.Replacements, 1)
+	a.Equal("DSC0002.jpg", plan.Replacements[0].Name)
+	a.Len(plan.Unchanged, 1)
+	a.Equal("DSC0001.jpg", plan.Unchanged[0].Name)
+	a.Len(plan.RemoteOnly, 1)
+	a.Equal("DSC0004.jpg", plan.RemoteOnly[0].FileName)
+	// the size of the unchanged file is not included
+	a.Equal(int64(28), plan.Size())
+
+	var buf bytes.Buffer
+	a.NoError(plan.Write(&buf))
+	a.Contains(buf.String(), "upload    DSC0003.jpg")
+	a.Contains(buf.String(), "replace   DSC0002.jpg")
+	a.Contains(buf.String(), "unchanged DSC0001.jpg")
+	a.Contains(buf.String(), "remote    DSC0004.jpg")
+	a.Contains(buf.String(), "1 upload, 1 replace, 1 unchanged, 0 skip, 1 remote")
+
+	ups, err := planner.Execute(context.TODO(), plan, false)
+	a.NoError(err)
+	a.Len(ups, 2)
+	a.Equal(int32(2), uploads.Load())
+	a.Equal(int32(0), deletes.Load())
+
+	plan.Uploads, plan.Replacements = nil, nil
+	ups, err = planner.Execute(context.TODO(), plan, true)
+	a.NoError(err)
+	a.Empty(ups)
+	a.Equal(int32(1), deletes.Load())
+}
+
+func TestPlannerError(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer svr.Close()
+
+	mg, err := smugmug.NewClient(smugmug.WithBaseURL(svr.URL), smugmug.WithUploadURL(svr.URL))
+	a.NoError(err)
+
+	fs := new(afero.MemMapFs)
+	fsup, err := filesystem.NewFsUploadable("QpLn7s")
+	a.NoError(err)
+
+	planner := filesystem.NewPlanner(mg, fs, fsup)
+	plan, err := planner.Plan(context.TODO(), "QpLn7s", []string{"DSC0001.jpg"})
+	a.Error(err)
+	a.Nil(plan)
+
+	plan = &filesystem.Plan{
+		AlbumKey:   "QpLn7s",
+		RemoteOnly: []*smugmug.Image{{FileName: "DSC0004.jpg", ImageKey: "Lm3TqR8"}},
+	}
+	ups, err := planner.Execute(context.TODO(), plan, true)
+	a.Error(err)
+	a.Empty(ups)
+}
+
+func TestPlannerPrune(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	var deletes atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/album/QpLn7s!images", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "testdata/album_images_QpLn7s.json")
+	})
+	mux.HandleFunc("/album/QpLn7s/image/{imageKey}", func(w http.ResponseWriter, r *http.Request) {
+		deletes.Add(1)
+		http.ServeFile(w, r, "testdata/image_743XwH7_delete.json")
+	})
+	mux.HandleFunc("/{$}", func(w http.ResponseWriter, r *http.Request) {
+		a.Equal("/api/v2/album/QpLn7s", r.Header.Get("X-Smug-AlbumUri"))
+		if r.Header.Get("X-Smug-FileName") == "DSC0003.jpg" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		http.ServeFile(w, r, "testdata/upload_CVvj69L.json")
+	})
+	svr := httptest.NewServer(mux)
+	defer svr.Close()
+
+	mg, err := smugmug.NewClient(
+		smugmug.WithBaseURL(svr.URL), smugmug.WithUploadURL(svr.URL), smugmug.WithContinueOnError(true))
+	a.NoError(err)
+
+	fs := new(afero.MemMapFs)
+	for _, filename := range []string{"DSC0001.jpg", "DSC0002.jpg", "DSC0003.jpg"} {
+		a.NoError(afero.WriteFile(fs, filename, []byte("this is a test"), 0644))
+	}
+	a.NoError(afero.WriteFile(fs, "DSC0004.jpg", []byte("this is a test"), 0644))
+	// the album key of the Uploadables is replaced by the album being planned
+	fsup, err := filesystem.NewFsUploadable("elsewhere")
+	a.NoError(err)
+	fsup.Pre(filesystem.Exclude("DSC0004.jpg"))
+
+	planner := filesystem.NewPlanner(mg, fs, fsup)
+	plan, err := planner.Plan(
+		context.TODO(), "QpLn7s", []string{"DSC0001.jpg", "DSC0002.jpg", "DSC0003.jpg", "DSC0004.jpg"})
+	a.NoError(err)
+	// a skipped local file is not remote only
+	a.Empty(plan.RemoteOnly)
+	a.Len(plan.Skipped, 1)
+	var buf bytes.Buffer
+	a.NoError(plan.Write(&buf))
+	a.Contains(buf.String(), "skip      DSC0004.jpg")
+	a.Contains(buf.String(), "1 upload, 1 replace, 1 unchanged, 1 skip, 0 remote")
+	for _, up := range append(plan.Uploads, plan.Replacements...) {
+		a.Equal("QpLn7s", up.AlbumKey)
+	}
+
+	ups, err := planner.Execute(context.TODO(), plan, true)
+	a.ErrorContains(err, "not pruning")
+	a.ErrorContains(err, "1 upload(s) failed")
+	a.Len(ups, 3)
+	a.Equal(int32(0), deletes.Load())
+}
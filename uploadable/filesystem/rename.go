@@ -0,0 +1,154 @@
+package filesystem
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/spf13/afero"
+
+	"github.com/bzimmer/smugmug"
+)
+
+// maxCollisions bounds the number of suffixes tried to find a name not used in the album
+const maxCollisions = 1000
+
+// SanitizeName replaces the characters SmugMug rejects in filenames, path separators, reserved punctuation
+// and control characters, with underscores and removes leading and trailing spaces and dots
+func SanitizeName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	name = strings.Trim(name, " .")
+	if name == "" {
+		return "_"
+	}
+	return name
+}
+
+// renamer tracks the names used in each album
+type renamer struct {
+	afs      afero.Fs
+	template string
+	images   ImageLookup
+	mu       sync.Mutex
+	seqs     map[string]int
+	used     map[string]map[string]bool
+}
+
+// Rename sets the name of the Uploadable by expanding `template` and records the original name
+// Placeholders are enclosed in braces and are `name` and `ext` (without the dot) of the filename, `parentdir`,
+// `date` and `time` of the EXIF DateTimeOriginal or modification time, and `seq`, the four digit sequence
+// number of the Uploadable in its album, eg `{date}_{seq}_{parentdir}.{ext}`
+// The name is sanitized and suffixed with `-1`, `-2`, etc if already used by another Uploadable in the album
+// or by one of `images` with a different MD5
+// Rename should be registered before UseFuncs comparing names such as Skip and Replace
+func Rename(afs afero.Fs, template string, images ImageLookup) UseFunc {
+	if images == nil {
+		images = Images(nil)
+	}
+	r := &renamer{
+		afs:      afs,
+		template: template,
+		images:   images,
+		seqs:     make(map[string]int),
+		used:     make(map[string]map[string]bool),
+	}
+	return r.rename
+}
+
+func (r *renamer) rename(up *smugmug.Uploadable) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	album := albumID(up)
+	seq := r.seqs[album] + 1
+	name, err := r.expand(up, seq)
+	if err != nil {
+		return err
+	}
+	name, err = r.unique(up, SanitizeName(name))
+	if err != nil {
+		return err
+	}
+	r.seqs[album] = seq
+	if up.OriginalName == "" {
+		up.OriginalName = up.Name
+	}
+	up.Name = name
+	return nil
+}
+
+// albumID identifies the album of the Uploadable, which may not be created until the Uploadable is uploaded
+func albumID(up *smugmug.Uploadable) string {
+	if up.AlbumPath != "" {
+		return "path:" + up.AlbumPath
+	}
+	return "key:" + up.AlbumKey
+}
+
+// expand replaces the placeholders in the template with the attributes of the Uploadable
+func (r *renamer) expand(up *smugmug.Uploadable, seq int) (string, error) {
+	var err error
+	name := templateRE.ReplaceAllStringFunc(r.template, func(s string) string {
+		ext := filepath.Ext(up.Name)
+		switch placeholder := s[1 : len(s)-1]; placeholder {
+		case "name":
+			return strings.TrimSuffix(up.Name, ext)
+		case "ext":
+			return strings.TrimPrefix(ext, ".")
+		case "parentdir":
+			return filepath.Base(filepath.Dir(up.Path))
+		case "seq":
+			return fmt.Sprintf("%04d", seq)
+		case "date", "time":
+			t, terr := dateTaken(r.afs, up)
+			if terr != nil {
+				err = terr
+				return s
+			}
+			if placeholder == "date" {
+				return t.Format("2006-01-02")
+			}
+			return t.Format("150405")
+		default:
+			err = fmt.Errorf("unknown placeholder `%s` in template `%s`", s, r.template)
+			return s
+		}
+	})
+	return name, err
+}
+
+// unique returns the name, suffixed if necessary, not used by another image in the album
+func (r *renamer) unique(up *smugmug.Uploadable, name string) (string, error) {
+	album := albumID(up)
+	used, ok := r.used[album]
+	if !ok {
+		used = make(map[string]bool)
+		r.used[album] = used
+	}
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for i := range maxCollisions {
+		candidate := name
+		if i > 0 {
+			candidate = base + "-" + strconv.Itoa(i) + ext
+		}
+		key := strings.ToLower(candidate)
+		if used[key] {
+			continue
+		}
+		if img, ok := r.images.Image(candidate); ok && img.ArchivedMD5 != up.MD5 {
+			continue
+		}
+		used[key] = true
+		return candidate, nil
+	}
+	return "", fmt.Errorf("failed to find an unused name for `%s`", name)
+}
@@ -0,0 +1,100 @@
+package filesystem_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bzimmer/smugmug/uploadable/filesystem"
+)
+
+func TestSanitizeName(t *testing.T) {
+	t.Parallel()
+
+	for name, sanitized := range map[string]string{
+		"DSC0001.jpg":           "DSC0001.jpg",
+		"a/b\\c:d*e?f\"g<h>i|j": "a_b_c_d_e_f_g_h_i_j",
+		" .hidden.jpg. ":        "hidden.jpg",
+		"tab\there.jpg":         "tab_here.jpg",
+		"Café.jpg":              "Café.jpg",
+		"..":                    "_",
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, sanitized, filesystem.SanitizeName(name))
+		})
+	}
+}
+
+func TestRename(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	data, err := os.ReadFile("testdata/exif_gps.jpg")
+	a.NoError(err)
+	modTime := time.Date(2023, time.July, 8, 9, 10, 11, 0, time.Local)
+
+	fs := afero.NewMemMapFs()
+	for _, filename := range []string{"Hike/DSC0001.jpg", "Hike/DSC0002.JPG", "Hike: Day 2/DSC0003.jpg"} {
+		a.NoError(afero.WriteFile(fs, filename, data, 0644))
+	}
+	a.NoError(afero.WriteFile(fs, "Hike/notes.txt", []byte("this is a test"), 0644))
+	a.NoError(fs.Chtimes("Hike/notes.txt", modTime, modTime))
+
+	images := filesystem.Images{
+		// a different image with the name of the first upload
+		"2024-03-04_0001_Hike.jpg": {FileName: "2024-03-04_0001_Hike.jpg", ArchivedMD5: "different"},
+	}
+
+	fsu, err := filesystem.NewFsUploadable("7dFHSm")
+	a.NoError(err)
+	fsu.Use(filesystem.Rename(fs, "{date}_{seq}_{parentdir}.{ext}", images))
+
+	for _, tt := range []struct {
+		filename string
+		name     string
+	}{
+		{filename: "Hike/DSC0001.jpg", name: "2024-03-04_0001_Hike-1.jpg"},
+		{filename: "Hike/DSC0002.JPG", name: "2024-03-04_0002_Hike.JPG"},
+		{filename: "Hike: Day 2/DSC0003.jpg", name: "2024-03-04_0003_Hike_ Day 2.jpg"},
+		{filename: "Hike/notes.txt", name: "2023-07-08_0004_Hike.txt"},
+	} {
+		up, err := fsu.Uploadable(fs, tt.filename)
+		a.NoError(err)
+		a.Equal(tt.name, up.Name)
+		a.Equal(tt.filename, up.Path)
+		a.Equal(filepath.Base(tt.filename), up.OriginalName)
+	}
+
+	// names used in the album are not reused
+	fsu, err = filesystem.NewFsUploadable("7dFHSm")
+	a.NoError(err)
+	fsu.Use(filesystem.Rename(fs, "{parentdir}-{time}.jpg", nil))
+	for _, name := range []string{"Hike-101112.jpg", "Hike-101112-1.jpg"} {
+		up, err := fsu.Uploadable(fs, "Hike/DSC0001.jpg")
+		a.NoError(err)
+		a.Equal(name, up.Name)
+	}
+
+	// an existing image with the same name and md5 is the same image
+	up, err := fsu.Uploadable(fs, "Hike/DSC0001.jpg")
+	a.NoError(err)
+	fsu, err = filesystem.NewFsUploadable("7dFHSm")
+	a.NoError(err)
+	fsu.Use(filesystem.Rename(fs, "{name}.{ext}", filesystem.Images{
+		"DSC0001.jpg": {FileName: "DSC0001.jpg", ArchivedMD5: up.MD5}}))
+	up, err = fsu.Uploadable(fs, "Hike/DSC0001.jpg")
+	a.NoError(err)
+	a.Equal("DSC0001.jpg", up.Name)
+
+	fsu, err = filesystem.NewFsUploadable("7dFHSm")
+	a.NoError(err)
+	fsu.Use(filesystem.Rename(fs, "{unknown}.{ext}", nil))
+	up, err = fsu.Uploadable(fs, "Hike/DSC0001.jpg")
+	a.ErrorContains(err, "unknown placeholder `{unknown}`")
+	a.Nil(up)
+}
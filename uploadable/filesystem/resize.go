@@ -0,0 +1,117 @@
+package filesystem
+
+import (
+	"bytes"
+	"crypto/md5" //nolint:gosec // used to match md5 at smugmug
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	"golang.org/x/image/draw"
+
+	"github.com/bzimmer/smugmug"
+	"github.com/bzimmer/smugmug/uploadable/exif"
+)
+
+// Resize downscales JPEG and PNG Uploadables larger than `maximum` pixels in either dimension to fit within
+// `maximum`, re-encoding JPEGs with `quality` (1-100) and keeping their metadata, including the orientation
+// The pixel dimensions recorded in the EXIF metadata are updated and the embedded thumbnail is removed
+// Images are never upscaled and images within bounds or of other media types are unchanged and streamed
+// when uploaded; only images being resized are read into memory
+func Resize(maximum, quality int) UseFunc {
+	return func(up *smugmug.Uploadable) error {
+		if maximum < 1 {
+			return fmt.Errorf("invalid maximum dimension {%d}", maximum)
+		}
+		if quality < 1 || quality > 100 {
+			return fmt.Errorf("invalid quality {%d}", quality)
+		}
+		if up.ContentType != "image/jpeg" && up.ContentType != "image/png" {
+			return nil
+		}
+		if rs, ok := up.Reader.(io.ReadSeeker); ok {
+			// the dimensions are read from the header so an image within bounds is streamed when uploaded
+			cfg, _, err := image.DecodeConfig(rs)
+			if err != nil {
+				return fmt.Errorf("%s: %w", up.Name, err)
+			}
+			if _, err = rs.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+			if cfg.Width <= maximum && cfg.Height <= maximum {
+				if c, ok := up.Reader.(io.Closer); ok {
+					return c.Close()
+				}
+				return nil
+			}
+		}
+		data, err := io.ReadAll(up.Reader)
+		if err != nil {
+			return err
+		}
+		if c, ok := up.Reader.(io.Closer); ok {
+			if err = c.Close(); err != nil {
+				return err
+			}
+		}
+		up.Reader = bytes.NewReader(data)
+
+		cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("%s: %w", up.Name, err)
+		}
+		if cfg.Width <= maximum && cfg.Height <= maximum {
+			return nil
+		}
+		img, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("%s: %w", up.Name, err)
+		}
+		data, err = encode(up.ContentType, data, scale(img, maximum), quality)
+		if err != nil {
+			return fmt.Errorf("%s: %w", up.Name, err)
+		}
+
+		up.Reader = bytes.NewReader(data)
+		up.Size = int64(len(data))
+		up.MD5 = fmt.Sprintf("%x", md5.Sum(data)) //nolint:gosec // required for smugmug
+		return nil
+	}
+}
+
+// scale returns the image resized to fit within `maximum` pixels keeping the aspect ratio
+func scale(img image.Image, maximum int) image.Image {
+	b := img.Bounds()
+	f := float64(maximum) / float64(max(b.Dx(), b.Dy()))
+	width := max(1, int(float64(b.Dx())*f+0.5))  //nolint:mnd // round
+	height := max(1, int(float64(b.Dy())*f+0.5)) //nolint:mnd // round
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+	return dst
+}
+
+// encode encodes the image as the content type, copying the metadata of the original JPEG updated for the
+// dimensions of the image
+func encode(contentType string, original []byte, img image.Image, quality int) ([]byte, error) {
+	var buf bytes.Buffer
+	if contentType == "image/png" {
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+	b := img.Bounds()
+	metadata, err := exif.Resized(original, b.Dx(), b.Dy())
+	if err != nil {
+		return nil, err
+	}
+	if err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, err
+	}
+	// the encoder writes no application segments so the metadata follows the SOI marker
+	encoded := buf.Bytes()
+	return append(append(append(make([]byte, 0, len(metadata)+len(encoded)), encoded[:2]...), metadata...),
+		encoded[2:]...), nil
+}
@@ -0,0 +1,157 @@
+package filesystem_test
+
+import (
+	"bytes"
+	"crypto/md5" //nolint:gosec // used to match md5 at smugmug
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bzimmer/smugmug"
+	"github.com/bzimmer/smugmug/uploadable/exif"
+	"github.com/bzimmer/smugmug/uploadable/filesystem"
+)
+
+// gradient returns an image of the size
+func gradient(width, height int) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			img.Set(x, y, color.NRGBA{R: uint8(x % 256), G: uint8(y % 256), B: 128, A: 255}) //nolint:gosec // modulo
+		}
+	}
+	return img
+}
+
+// withExif returns a JPEG of the size with the EXIF metadata of the `exif_gps.jpg` fixture
+func withExif(t *testing.T, width, height int) []byte {
+	t.Helper()
+	a := assert.New(t)
+	data, err := os.ReadFile("testdata/exif_gps.jpg")
+	a.NoError(err)
+	metadata, err := exif.Metadata(data)
+	a.NoError(err)
+	var buf bytes.Buffer
+	a.NoError(jpeg.Encode(&buf, gradient(width, height), nil))
+	encoded := buf.Bytes()
+	return append(append(bytes.Clone(encoded[:2]), metadata...), encoded[2:]...)
+}
+
+func TestResize(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, gradient(50, 300)))
+	tall := buf.Bytes()
+
+	tests := []struct {
+		name     string
+		filename string
+		data     []byte
+		maximum  int
+		width    int
+		height   int
+		resized  bool
+		streamed bool
+	}{
+		{
+			name:     "jpeg",
+			filename: "DSC0001.jpg",
+			data:     withExif(t, 200, 100),
+			maximum:  100,
+			width:    100,
+			height:   50,
+			resized:  true,
+		},
+		{
+			name:     "png",
+			filename: "DSC0002.png",
+			data:     tall,
+			maximum:  100,
+			width:    17,
+			height:   100,
+			resized:  true,
+		},
+		{
+			name:     "within bounds",
+			filename: "DSC0003.jpg",
+			data:     withExif(t, 200, 100),
+			maximum:  200,
+			width:    200,
+			height:   100,
+			streamed: true,
+		},
+		{
+			name:     "not an image",
+			filename: "DSC0004.txt",
+			data:     []byte("this is a test"),
+			maximum:  1,
+			streamed: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			fs := afero.NewMemMapFs()
+			a.NoError(afero.WriteFile(fs, tt.filename, tt.data, 0644))
+
+			fsu, err := filesystem.NewFsUploadable("7dFHSm")
+			a.NoError(err)
+			fsu.Use(filesystem.Resize(tt.maximum, 85))
+
+			up, err := fsu.Uploadable(fs, tt.filename)
+			a.NoError(err)
+			_, buffered := up.Reader.(*bytes.Reader)
+			a.Equal(tt.streamed, !buffered)
+			b, err := io.ReadAll(up.Reader)
+			a.NoError(err)
+			a.Equal(int64(len(b)), up.Size)
+			a.Equal(fmt.Sprintf("%x", md5.Sum(b)), up.MD5) //nolint:gosec // required for smugmug
+			if !tt.resized {
+				a.Equal(tt.data, b)
+			}
+			if tt.width == 0 {
+				return
+			}
+
+			cfg, _, err := image.DecodeConfig(bytes.NewReader(b))
+			a.NoError(err)
+			a.Equal(tt.width, cfg.Width)
+			a.Equal(tt.height, cfg.Height)
+			if up.ContentType != "image/jpeg" {
+				return
+			}
+			// the metadata, including the orientation, is kept with the dimensions updated
+			original, err := exif.Resized(tt.data, tt.width, tt.height)
+			a.NoError(err)
+			metadata, err := exif.Metadata(b)
+			a.NoError(err)
+			a.Equal(original, metadata)
+			x, err := exif.Decode(bytes.NewReader(b))
+			a.NoError(err)
+			a.False(x.DateTimeOriginal.IsZero())
+		})
+	}
+}
+
+func TestResizeInvalid(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	up := &smugmug.Uploadable{Name: "DSC0001.jpg", ContentType: "image/jpeg", Reader: bytes.NewReader([]byte("bad"))}
+	a.Error(filesystem.Resize(0, 85)(up))
+	a.Error(filesystem.Resize(100, 0)(up))
+	a.Error(filesystem.Resize(100, 101)(up))
+	a.ErrorContains(filesystem.Resize(100, 85)(up), "DSC0001.jpg")
+}
@@ -0,0 +1,206 @@
+package filesystem
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/spf13/afero"
+
+	"github.com/bzimmer/smugmug"
+)
+
+// defaultPrivacy is the privacy of folders and albums created by an AlbumResolver unless specified
+const defaultPrivacy = "Unlisted"
+
+// AlbumResolver finds the album at a path of folder names ending in the album name, creating any missing
+// folders and the album as needed
+// Resolved albums are cached so each path is queried once
+type AlbumResolver struct {
+	client  *smugmug.Client
+	privacy string
+	mu      sync.Mutex
+	root    string
+	nodes   map[string]*smugmug.Node
+	missing map[string]bool
+}
+
+// NewAlbumResolver returns a new AlbumResolver creating folders and albums with `privacy`
+// If `privacy` is empty `Unlisted` is used
+func NewAlbumResolver(client *smugmug.Client, privacy string) *AlbumResolver {
+	if privacy == "" {
+		privacy = defaultPrivacy
+	}
+	return &AlbumResolver{
+		client:  client,
+		privacy: privacy,
+		nodes:   make(map[string]*smugmug.Node),
+		missing: make(map[string]bool),
+	}
+}
+
+// NewNodeAlbumResolver returns a new AlbumResolver resolving album paths relative to the node `nodeID`
+// rather than the authenticated user's root node
+func NewNodeAlbumResolver(client *smugmug.Client, nodeID, privacy string) *AlbumResolver {
+	r := NewAlbumResolver(client, privacy)
+	r.root = nodeID
+	return r
+}
+
+// AlbumKey returns the key of the album at `albumPath`, eg `/Photos/2024/2024-03-04`, creating any
+// missing folders and the album
+// The path is relative to the authenticated user's root node unless the resolver was created with a node
+func (r *AlbumResolver) AlbumKey(ctx context.Context, albumPath string) (string, error) {
+	albumKey, _, err := r.resolve(ctx, albumPath, true)
+	return albumKey, err
+}
+
+// Lookup returns the key of the album at `albumPath` and true if the album exists
+// Nothing is created; a missing folder or album returns false
+func (r *AlbumResolver) Lookup(ctx context.Context, albumPath string) (string, bool, error) {
+	return r.resolve(ctx, albumPath, false)
+}
+
+// NewAlbumPathUploadable returns an FsUploadable which uploads each file into the album at `albumPath`
+// The folders and album are created only when a file is uploaded if they do not exist
+func NewAlbumPathUploadable(ctx context.Context, resolver *AlbumResolver, albumPath string) (FsUploadable, error) {
+	if resolver == nil {
+		return nil, errors.New("missing resolver")
+	}
+	if len(split(albumPath)) == 0 {
+		return nil, errors.New("missing album path")
+	}
+	return &fsUploadable{album: func(_ afero.Fs, up *smugmug.Uploadable) (string, error) {
+		return resolver.album(ctx, up, albumPath)
+	}}, nil
+}
+
+// album sets the album path of the Uploadable and returns the key of the album if it exists
+// If the album does not exist it is created by the Uploadable's CreateAlbum when uploaded so
+// files which are skipped, or fail, do not leave empty albums
+func (r *AlbumResolver) album(ctx context.Context, up *smugmug.Uploadable, albumPath string) (string, error) {
+	up.AlbumPath = albumPath
+	albumKey, ok, err := r.Lookup(ctx, albumPath)
+	if err != nil || ok {
+		return albumKey, err
+	}
+	up.CreateAlbum = func(ctx context.Context) (string, error) {
+		return r.AlbumKey(ctx, albumPath)
+	}
+	return "", nil
+}
+
+// resolve walks the names of the album path, creating missing nodes if `create` is true
+func (r *AlbumResolver) resolve(ctx context.Context, albumPath string, create bool) (string, bool, error) {
+	names := split(albumPath)
+	if len(names) == 0 {
+		return "", false, fmt.Errorf("invalid album path `%s`", albumPath)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	parent, err := r.rootID(ctx)
+	if err != nil {
+		return "", false, err
+	}
+	var node *smugmug.Node
+	for i := range names {
+		typ := smugmug.TypeFolder
+		if i == len(names)-1 {
+			typ = smugmug.TypeAlbum
+		}
+		node, err = r.child(ctx, parent, strings.Join(names[:i+1], "/"), names[i], typ, create)
+		if err != nil {
+			return "", false, err
+		}
+		if node == nil {
+			return "", false, nil
+		}
+		parent = node.NodeID
+	}
+	if node.URIs.Album == nil {
+		return "", false, fmt.Errorf("missing album uri for node `%s`", node.NodeID)
+	}
+	return path.Base(node.URIs.Album.URI), true, nil
+}
+
+// rootID returns the id of the authenticated user's root node
+func (r *AlbumResolver) rootID(ctx context.Context) (string, error) {
+	if r.root != "" {
+		return r.root, nil
+	}
+	user, err := r.client.User.AuthUser(ctx)
+	if err != nil {
+		return "", err
+	}
+	if user.URIs.Node == nil {
+		return "", errors.New("missing root node uri")
+	}
+	r.root = path.Base(user.URIs.Node.URI)
+	return r.root, nil
+}
+
+// child returns the node at `key`, the path of names ending in `name`, verifying its type
+// A missing node is created if `create` is true, otherwise nil is returned
+func (r *AlbumResolver) child(
+	ctx context.Context, parentID, key, name, typ string, create bool) (*smugmug.Node, error) {
+	node, ok := r.nodes[key]
+	if !ok {
+		if r.missing[key] && !create {
+			return nil, nil //nolint:nilnil // the node is known not to exist
+		}
+		var err error
+		node, err = r.find(ctx, parentID, name)
+		if err != nil {
+			return nil, err
+		}
+		if node == nil {
+			if !create {
+				r.missing[key] = true
+				return nil, nil //nolint:nilnil // the node does not exist
+			}
+			node, err = r.client.Node.Create(ctx, parentID, &smugmug.Nodelet{
+				Type: typ, Name: name, URLName: smugmug.URLName(name), Privacy: r.privacy})
+			if err != nil {
+				return nil, err
+			}
+		}
+		delete(r.missing, key)
+		r.nodes[key] = node
+	}
+	if node.Type != typ {
+		return nil, fmt.Errorf("expected `%s` to be a %s not a %s", key, strings.ToLower(typ), strings.ToLower(node.Type))
+	}
+	return node, nil
+}
+
+// find returns the child of `parentID` named `name` or nil if it does not exist
+func (r *AlbumResolver) find(ctx context.Context, parentID, name string) (*smugmug.Node, error) {
+	var node *smugmug.Node
+	urlName := smugmug.URLName(name)
+	if err := r.client.Node.ChildrenIter(ctx, parentID, func(n *smugmug.Node) (bool, error) {
+		if n.Name == name || strings.EqualFold(n.URLName, urlName) {
+			node = n
+			return false, nil
+		}
+		return true, nil
+	}); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// split returns the non-empty names of the path
+func split(p string) []string {
+	var names []string
+	for name := range strings.SplitSeq(p, "/") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
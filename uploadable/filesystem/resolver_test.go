@@ -0,0 +1,208 @@
+package filesystem_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bzimmer/smugmug"
+	"github.com/bzimmer/smugmug/uploadable/filesystem"
+)
+
+// nodes is a minimal node hierarchy served over http
+type nodes struct {
+	mu       sync.Mutex
+	children map[string][]*smugmug.Node
+	created  []string
+	queried  int
+}
+
+func newNodes() *nodes {
+	photos := &smugmug.Node{Nodelet: smugmug.Nodelet{Type: smugmug.TypeFolder, Name: "Photos", URLName: "Photos"},
+		NodeID: "n1"}
+	album := &smugmug.Node{Nodelet: smugmug.Nodelet{Type: smugmug.TypeAlbum, Name: "Misc", URLName: "Misc"},
+		NodeID: "n2", URIs: smugmug.NodeURIs{Album: &smugmug.APIEndpoint{URI: "/api/v2/album/a2"}}}
+	return &nodes{children: map[string][]*smugmug.Node{"root": {photos, album}}}
+}
+
+func (n *nodes) server(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /!authuser", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"Response":{"User":{"Uris":{"Node":{"Uri":"/api/v2/node/root"}}}}}`))
+	})
+	mux.HandleFunc("GET /node/{nodeID}", func(w http.ResponseWriter, r *http.Request) {
+		nodeID, ok := strings.CutSuffix(r.PathValue("nodeID"), "!children")
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		n.queried++
+		children := n.children[nodeID]
+		res := map[string]any{"Response": map[string]any{"Node": children,
+			"Pages": smugmug.Pages{Total: len(children), Start: 1, Count: len(children)}}}
+		assert.NoError(t, json.NewEncoder(w).Encode(res))
+	})
+	mux.HandleFunc("POST /node/{nodeID}", func(w http.ResponseWriter, r *http.Request) {
+		nodeID, _ := strings.CutSuffix(r.PathValue("nodeID"), "!children")
+		nodelet := &smugmug.Nodelet{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(nodelet))
+		if nodelet.Name == "fail" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		id := fmt.Sprintf("c%d", len(n.created))
+		node := &smugmug.Node{Nodelet: *nodelet, NodeID: id}
+		if nodelet.Type == smugmug.TypeAlbum {
+			node.URIs.Album = &smugmug.APIEndpoint{URI: "/api/v2/album/" + id}
+		}
+		n.children[nodeID] = append(n.children[nodeID], node)
+		n.created = append(n.created, nodelet.Type+":"+nodelet.Name+":"+nodelet.URLName+":"+nodelet.Privacy)
+		assert.NoError(t, json.NewEncoder(w).Encode(map[string]any{"Response": map[string]any{"Node": node}}))
+	})
+	mux.HandleFunc("PUT /{$}", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "testdata/upload_CVvj69L.json")
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestAlbumResolver(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	n := newNodes()
+	svr := n.server(t)
+	defer svr.Close()
+
+	mg, err := smugmug.NewClient(smugmug.WithBaseURL(svr.URL))
+	a.NoError(err)
+	resolver := filesystem.NewAlbumResolver(mg, "")
+
+	albumKey, err := resolver.AlbumKey(context.TODO(), "/Misc")
+	a.NoError(err)
+	a.Equal("a2", albumKey)
+
+	albumKey, err = resolver.AlbumKey(context.TODO(), "/Photos/2024/2024-03-04")
+	a.NoError(err)
+	a.Equal("c1", albumKey)
+	a.Equal([]string{"Folder:2024:2024:Unlisted", "Album:2024-03-04:2024-03-04:Unlisted"}, n.created)
+
+	// resolved paths are cached
+	queried := n.queried
+	albumKey, err = resolver.AlbumKey(context.TODO(), "Photos//2024/2024-03-04/")
+	a.NoError(err)
+	a.Equal("c1", albumKey)
+	a.Equal(queried, n.queried)
+
+	albumKey, err = resolver.AlbumKey(context.TODO(), "/Photos/2024/2024-03-05")
+	a.NoError(err)
+	a.Equal("c2", albumKey)
+	a.Len(n.created, 3)
+
+	for _, albumPath := range []string{"", "/", "/Photos", "/Misc/2024", "/Photos/fail"} {
+		albumKey, err = resolver.AlbumKey(context.TODO(), albumPath)
+		a.Error(err, albumPath)
+		a.Empty(albumKey)
+	}
+}
+
+func TestAlbumResolverLookup(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	n := newNodes()
+	svr := n.server(t)
+	defer svr.Close()
+
+	mg, err := smugmug.NewClient(smugmug.WithBaseURL(svr.URL))
+	a.NoError(err)
+	resolver := filesystem.NewAlbumResolver(mg, "")
+
+	albumKey, ok, err := resolver.Lookup(context.TODO(), "/Misc")
+	a.NoError(err)
+	a.True(ok)
+	a.Equal("a2", albumKey)
+
+	// missing folders and albums are not created
+	albumKey, ok, err = resolver.Lookup(context.TODO(), "/Photos/2024/2024-03-04")
+	a.NoError(err)
+	a.False(ok)
+	a.Empty(albumKey)
+	a.Empty(n.created)
+
+	// missing paths are cached until created
+	queried := n.queried
+	_, ok, err = resolver.Lookup(context.TODO(), "/Photos/2024/2024-03-04")
+	a.NoError(err)
+	a.False(ok)
+	a.Equal(queried, n.queried)
+
+	albumKey, err = resolver.AlbumKey(context.TODO(), "/Photos/2024/2024-03-04")
+	a.NoError(err)
+	a.Equal("c1", albumKey)
+	albumKey, ok, err = resolver.Lookup(context.TODO(), "/Photos/2024/2024-03-04")
+	a.NoError(err)
+	a.True(ok)
+	a.Equal("c1", albumKey)
+
+	for _, albumPath := range []string{"", "/Photos", "/Misc/2024"} {
+		_, ok, err = resolver.Lookup(context.TODO(), albumPath)
+		a.Error(err, albumPath)
+		a.False(ok)
+	}
+}
+
+func TestAlbumPathUploadable(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	n := newNodes()
+	svr := n.server(t)
+	defer svr.Close()
+
+	mg, err := smugmug.NewClient(smugmug.WithBaseURL(svr.URL))
+	a.NoError(err)
+	resolver := filesystem.NewAlbumResolver(mg, "")
+
+	fsu, err := filesystem.NewAlbumPathUploadable(context.TODO(), nil, "/Misc")
+	a.Error(err)
+	a.Nil(fsu)
+	fsu, err = filesystem.NewAlbumPathUploadable(context.TODO(), resolver, "/")
+	a.Error(err)
+	a.Nil(fsu)
+
+	fs := afero.NewMemMapFs()
+	a.NoError(afero.WriteFile(fs, "DSC0001.jpg", []byte("this is a test"), 0644))
+
+	fsu, err = filesystem.NewAlbumPathUploadable(context.TODO(), resolver, "/Misc")
+	a.NoError(err)
+	up, err := fsu.Uploadable(fs, "DSC0001.jpg")
+	a.NoError(err)
+	a.Equal("a2", up.AlbumKey)
+	a.Nil(up.CreateAlbum)
+
+	// a missing album is created when uploaded
+	fsu, err = filesystem.NewAlbumPathUploadable(context.TODO(), resolver, "/Photos/2024")
+	a.NoError(err)
+	up, err = fsu.Uploadable(fs, "DSC0001.jpg")
+	a.NoError(err)
+	a.Empty(up.AlbumKey)
+	a.Equal("/Photos/2024", up.AlbumPath)
+	a.Empty(n.created)
+	albumKey, err := up.CreateAlbum(context.TODO())
+	a.NoError(err)
+	a.Equal("c0", albumKey)
+	a.Equal([]string{"Album:2024:2024:Unlisted"}, n.created)
+}
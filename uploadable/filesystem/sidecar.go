@@ -0,0 +1,96 @@
+package filesystem
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/afero"
+
+	"github.com/bzimmer/smugmug"
+	"github.com/bzimmer/smugmug/uploadable/xmp"
+)
+
+// SidecarExt is the extension of XMP sidecar files
+const SidecarExt = ".xmp"
+
+// IsSidecar returns true if the file is an XMP sidecar
+func IsSidecar(filename string) bool {
+	return strings.EqualFold(filepath.Ext(filename), SidecarExt)
+}
+
+// SkipSidecars rejects XMP sidecar files before they are opened; XMP skips them regardless
+func SkipSidecars() PreFunc {
+	return func(_ afero.Fs, filename string) (bool, error) {
+		return !IsSidecar(filename), nil
+	}
+}
+
+// Sidecar returns the path of the XMP sidecar for the file or the empty string if none exists
+// Both the `DSC0001.xmp` and `DSC0001.jpg.xmp` conventions are supported, preferring the latter
+// since it can't be shared by a raw and a jpeg of the same name
+func Sidecar(afs afero.Fs, filename string) (string, error) {
+	base := strings.TrimSuffix(filename, filepath.Ext(filename))
+	for _, candidate := range []string{
+		filename + SidecarExt, filename + strings.ToUpper(SidecarExt),
+		base + SidecarExt, base + strings.ToUpper(SidecarExt),
+	} {
+		info, err := afs.Stat(candidate)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			continue
+		case err != nil:
+			return "", err
+		case info.Mode().IsRegular():
+			return candidate, nil
+		}
+	}
+	return "", nil
+}
+
+// XMP applies the title, description, keywords, rating and location of the file's XMP sidecar
+// The title and caption are set only if recorded and keywords are added to any existing keywords
+// A rating is added as a keyword of the form `rating:3` since SmugMug does not support ratings
+// The sidecars themselves are skipped rather than uploaded as images
+func XMP(afs afero.Fs) UseFunc {
+	return func(up *smugmug.Uploadable) error {
+		if IsSidecar(up.Path) {
+			return fmt.Errorf("%w: `%s` is a sidecar", ErrSkip, up.Path)
+		}
+		sidecar, err := Sidecar(afs, up.Path)
+		if err != nil || sidecar == "" {
+			return err
+		}
+		fp, err := afs.Open(sidecar)
+		if err != nil {
+			return err
+		}
+		defer fp.Close()
+		x, err := xmp.Decode(fp)
+		if err != nil {
+			return fmt.Errorf("%s: %w", sidecar, err)
+		}
+		if x.Title != "" {
+			up.Title = x.Title
+		}
+		if x.Description != "" {
+			up.Caption = x.Description
+		}
+		keywords := x.Keywords
+		if x.Rating > 0 {
+			keywords = append(keywords, fmt.Sprintf("rating:%d", x.Rating))
+		}
+		for _, keyword := range keywords {
+			if !slices.Contains(up.Keywords, keyword) {
+				up.Keywords = append(up.Keywords, keyword)
+			}
+		}
+		if x.GPS != nil {
+			up.Location = &smugmug.Location{Latitude: x.GPS.Latitude, Longitude: x.GPS.Longitude, Altitude: x.GPS.Altitude}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,119 @@
+package filesystem_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bzimmer/smugmug/uploadable/filesystem"
+)
+
+func TestSidecar(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		files    []string
+		filename string
+		sidecar  string
+	}{
+		{
+			name:     "none",
+			files:    []string{"DSC0001.jpg"},
+			filename: "DSC0001.jpg",
+		},
+		{
+			name:     "replaced extension",
+			files:    []string{"DSC0001.NEF", "DSC0001.xmp"},
+			filename: "DSC0001.NEF",
+			sidecar:  "DSC0001.xmp",
+		},
+		{
+			name:     "appended extension",
+			files:    []string{"DSC0001.jpg", "DSC0001.xmp", "DSC0001.jpg.XMP"},
+			filename: "DSC0001.jpg",
+			sidecar:  "DSC0001.jpg.XMP",
+		},
+		{
+			name:     "directory",
+			files:    []string{"DSC0001.jpg", "DSC0001.xmp/DSC0002.jpg"},
+			filename: "DSC0001.jpg",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			fs := afero.NewMemMapFs()
+			for _, file := range tt.files {
+				a.NoError(afero.WriteFile(fs, file, []byte(file), 0644))
+			}
+			sidecar, err := filesystem.Sidecar(fs, tt.filename)
+			a.NoError(err)
+			a.Equal(tt.sidecar, sidecar)
+		})
+	}
+}
+
+func TestXMP(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	data, err := os.ReadFile("testdata/lightroom.xmp")
+	a.NoError(err)
+
+	fs := afero.NewMemMapFs()
+	a.NoError(afero.WriteFile(fs, "photos/DSC0001.jpg", []byte("this is a test"), 0644))
+	a.NoError(afero.WriteFile(fs, "photos/DSC0001.xmp", data, 0644))
+	a.NoError(afero.WriteFile(fs, "photos/DSC0002.jpg", []byte("this is another test"), 0644))
+	a.NoError(afero.WriteFile(fs, "photos/DSC0003.jpg", []byte("this is a bad test"), 0644))
+	a.NoError(afero.WriteFile(fs, "photos/DSC0003.jpg.xmp", []byte("<x:xmpmeta"), 0644))
+
+	fsu, err := filesystem.NewFsUploadable("7dFHSm")
+	a.NoError(err)
+	fsu.Use(filesystem.Keywords("hiking", "summer"), filesystem.XMP(fs))
+
+	up, err := fsu.Uploadable(fs, "photos/DSC0001.jpg")
+	a.NoError(err)
+	a.Equal("Marmot", up.Title)
+	a.Equal("A marmot, sunning on a rock", up.Caption)
+	a.Equal([]string{"hiking", "summer", "marmot", "rating:4"}, up.Keywords)
+	a.NotNil(up.Location)
+	a.InDelta(47.606333, up.Location.Latitude, 1e-6)
+	a.InDelta(-122.331667, up.Location.Longitude, 1e-6)
+	a.InDelta(52.5, up.Location.Altitude, 1e-9)
+
+	up, err = fsu.Uploadable(fs, "photos/DSC0002.jpg")
+	a.NoError(err)
+	a.Empty(up.Title)
+	a.Nil(up.Location)
+	a.Equal([]string{"hiking", "summer"}, up.Keywords)
+
+	up, err = fsu.Uploadable(fs, "photos/DSC0003.jpg")
+	a.ErrorContains(err, "DSC0003.jpg.xmp")
+	a.Nil(up)
+
+	// sidecars are not uploaded themselves
+	a.NoError(fs.Remove("photos/DSC0003.jpg.xmp"))
+	fsu, err = filesystem.NewFsUploadable("7dFHSm")
+	a.NoError(err)
+	fsu.Use(filesystem.XMP(fs))
+	up, err = fsu.Uploadable(fs, "photos/DSC0001.xmp")
+	a.ErrorIs(err, filesystem.ErrSkip)
+	a.Nil(up)
+	paths, err := uploadablePaths(filesystem.NewFsUploadables(fs, []string{"photos"}, fsu))
+	a.NoError(err)
+	a.Equal([]string{"photos/DSC0001.jpg", "photos/DSC0002.jpg", "photos/DSC0003.jpg"}, paths)
+
+	// or may be skipped before they are opened
+	fsu, err = filesystem.NewFsUploadable("7dFHSm")
+	a.NoError(err)
+	fsu.Pre(filesystem.SkipSidecars())
+	paths, err = uploadablePaths(filesystem.NewFsUploadables(fs, []string{"photos"}, fsu))
+	a.NoError(err)
+	a.Equal([]string{"photos/DSC0001.jpg", "photos/DSC0002.jpg", "photos/DSC0003.jpg"}, paths)
+}
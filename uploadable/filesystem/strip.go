@@ -0,0 +1,47 @@
+package filesystem
+
+import (
+	"bytes"
+	"crypto/md5" //nolint:gosec // used to match md5 at smugmug
+	"fmt"
+	"io"
+
+	"github.com/bzimmer/smugmug"
+	"github.com/bzimmer/smugmug/uploadable/exif"
+)
+
+// Strip removes the location metadata from JPEG Uploadables, or if `all` is true all EXIF, XMP and
+// IPTC metadata, and clears the Uploadable's location so it is not sent with the upload
+// The contents are rewritten in memory and the MD5 and size are updated
+// Metadata cannot be stripped from other media types so they fail rather than being uploaded with their
+// metadata intact; register a PreFunc such as Extensions(".jpg", ".jpeg") to upload only JPEGs
+// Strip should be registered after any UseFunc setting the location, such as XMP or Geotag
+func Strip(all bool) UseFunc {
+	return func(up *smugmug.Uploadable) error {
+		up.Location = nil
+		if up.ContentType != "image/jpeg" {
+			return fmt.Errorf("cannot strip metadata from `%s` of type `%s`", up.Name, up.ContentType)
+		}
+		data, err := io.ReadAll(up.Reader)
+		if err != nil {
+			return err
+		}
+		if c, ok := up.Reader.(io.Closer); ok {
+			if err = c.Close(); err != nil {
+				return err
+			}
+		}
+		if all {
+			data, err = exif.StripAll(data)
+		} else {
+			data, err = exif.StripGPS(data)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", up.Name, err)
+		}
+		up.Reader = bytes.NewReader(data)
+		up.Size = int64(len(data))
+		up.MD5 = fmt.Sprintf("%x", md5.Sum(data)) //nolint:gosec // required for smugmug
+		return nil
+	}
+}
@@ -0,0 +1,97 @@
+package filesystem_test
+
+import (
+	"bytes"
+	"crypto/md5" //nolint:gosec // used to match md5 at smugmug
+	"fmt"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bzimmer/smugmug"
+	"github.com/bzimmer/smugmug/uploadable/exif"
+	"github.com/bzimmer/smugmug/uploadable/filesystem"
+)
+
+func TestStrip(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		filename string
+		all      bool
+		exif     bool
+		changed  bool
+		err      string
+	}{
+		{
+			name:     "gps",
+			filename: "exif_gps.jpg",
+			exif:     true,
+			changed:  true,
+		},
+		{
+			name:     "all",
+			filename: "exif_gps.jpg",
+			all:      true,
+			changed:  true,
+		},
+		{
+			name:     "no metadata",
+			filename: "no_exif.jpg",
+			all:      true,
+		},
+		{
+			name:     "not a jpeg",
+			filename: "hike.gpx",
+			all:      true,
+			err:      "cannot strip metadata from `hike.gpx`",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			data, err := os.ReadFile("testdata/" + tt.filename)
+			a.NoError(err)
+			fs := afero.NewMemMapFs()
+			a.NoError(afero.WriteFile(fs, tt.filename, data, 0644))
+
+			fsu, err := filesystem.NewFsUploadable("7dFHSm")
+			a.NoError(err)
+			fsu.Use(filesystem.Geolocation(47.6, -122.3, 0), filesystem.Strip(tt.all))
+
+			up, err := fsu.Uploadable(fs, tt.filename)
+			if tt.err != "" {
+				a.ErrorContains(err, tt.err)
+				a.Nil(up)
+				return
+			}
+			a.NoError(err)
+			a.Nil(up.Location)
+
+			b, err := io.ReadAll(up.Reader)
+			a.NoError(err)
+			a.Equal(tt.changed, !bytes.Equal(data, b))
+			a.Equal(int64(len(b)), up.Size)
+			a.Equal(fmt.Sprintf("%x", md5.Sum(b)), up.MD5) //nolint:gosec // required for smugmug
+
+			_, err = exif.Decode(bytes.NewReader(b))
+			if tt.exif {
+				a.NoError(err)
+				a.False(bytes.Contains(b, []byte{0x25, 0x88}))
+				return
+			}
+			a.ErrorIs(err, exif.ErrNoExif)
+		})
+	}
+
+	a := assert.New(t)
+	up := &smugmug.Uploadable{Name: "bad.jpg", ContentType: "image/jpeg", Reader: bytes.NewReader([]byte("bad"))}
+	a.ErrorIs(filesystem.Strip(false)(up), exif.ErrMalformed)
+}
@@ -1,7 +1,6 @@
 package filesystem
 
 import (
-	"bytes"
 	"crypto/md5" //nolint:gosec // used to match md5 at smugmug
 	"errors"
 	"fmt"
@@ -30,8 +29,11 @@ type FsUploadable interface {
 	Use(...UseFunc)
 }
 
+// headerLen is the number of bytes read for detecting the media type
+const headerLen = 512
+
 // ErrSkip is used to skip an Uploadable
-var ErrSkip = errors.New("skip")
+var ErrSkip = smugmug.ErrSkip
 
 // Extensions represents the valid list of extensions to upload
 func Extensions(extension ...string) PreFunc {
@@ -46,14 +48,65 @@ func Extensions(extension ...string) PreFunc {
 	}
 }
 
+// Media accepts only files detected as media supported by SmugMug
+// If content types (or prefixes such as `video/`) are specified only matching media is accepted
+func Media(contentTypes ...string) PreFunc {
+	return func(fs afero.Fs, filename string) (bool, error) {
+		fp, err := fs.Open(filename)
+		if err != nil {
+			return false, err
+		}
+		defer fp.Close()
+		header, err := readHeader(fp)
+		if err != nil {
+			return false, err
+		}
+		mt, err := smugmug.DetectMediaType(filename, header)
+		if err != nil {
+			if errors.Is(err, smugmug.ErrUnknownMediaType) {
+				return false, nil
+			}
+			return false, err
+		}
+		if len(contentTypes) == 0 {
+			return true, nil
+		}
+		for i := range contentTypes {
+			if strings.HasPrefix(mt.ContentType, contentTypes[i]) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+}
+
+// Journaled skips files recorded in the journal as uploaded and unchanged since, without hashing them
+func Journaled(journal *smugmug.Journal) PreFunc {
+	return func(fs afero.Fs, filename string) (bool, error) {
+		info, err := fs.Stat(filename)
+		if err != nil {
+			return false, err
+		}
+		_, ok := journal.Lookup(filename, info.Size(), info.ModTime())
+		return !ok, nil
+	}
+}
+
 // Skip checks if the Uploadable is already uploaded by comparing MD5s
 // If `force` is true the Uploadable will be always be uploaded
 func Skip(force bool, images map[string]*smugmug.Image) UseFunc {
+	return SkipIndex(force, Images(images))
+}
+
+// SkipIndex checks if the Uploadable is already uploaded by comparing MD5s with the image found in `images`
+// If `force` is true the Uploadable will be always be uploaded
+// If `images` is an *Index an image with the same normalized name is matched if no exact match exists
+func SkipIndex(force bool, images ImageLookup) UseFunc {
 	return func(up *smugmug.Uploadable) error {
-		if force {
+		if force || images == nil {
 			return nil
 		}
-		img, ok := images[up.Name]
+		img, ok := images.Image(up.Name)
 		if !ok {
 			return nil
 		}
@@ -67,11 +120,18 @@ func Skip(force bool, images map[string]*smugmug.Image) UseFunc {
 // Replace will update the Uploadable's URI if the image was already uploaded
 // If `update` is false the URI will not be updated
 func Replace(update bool, images map[string]*smugmug.Image) UseFunc {
+	return ReplaceIndex(update, Images(images))
+}
+
+// ReplaceIndex will update the Uploadable's URI if the image found in `images` was already uploaded
+// If `update` is false the URI will not be updated
+// If `images` is an *Index an image with the same normalized name is matched if no exact match exists
+func ReplaceIndex(update bool, images ImageLookup) UseFunc {
 	return func(up *smugmug.Uploadable) error {
-		if !update {
+		if !update || images == nil {
 			return nil
 		}
-		img, ok := images[up.Name]
+		img, ok := images.Image(up.Name)
 		if ok {
 			up.Replaces = img.URIs.Image.URI
 		}
@@ -79,10 +139,56 @@ func Replace(update bool, images map[string]*smugmug.Image) UseFunc {
 	}
 }
 
+// Title sets the title of the Uploadable
+func Title(title string) UseFunc {
+	return func(up *smugmug.Uploadable) error {
+		up.Title = title
+		return nil
+	}
+}
+
+// Caption sets the caption of the Uploadable
+func Caption(caption string) UseFunc {
+	return func(up *smugmug.Uploadable) error {
+		up.Caption = caption
+		return nil
+	}
+}
+
+// Keywords adds keywords to the Uploadable
+func Keywords(keywords ...string) UseFunc {
+	return func(up *smugmug.Uploadable) error {
+		up.Keywords = append(up.Keywords, keywords...)
+		return nil
+	}
+}
+
+// Hidden sets whether the Uploadable will be hidden in the album
+func Hidden(hidden bool) UseFunc {
+	return func(up *smugmug.Uploadable) error {
+		up.Hidden = hidden
+		return nil
+	}
+}
+
+// Geolocation sets the location of the Uploadable
+func Geolocation(latitude, longitude, altitude float64) UseFunc {
+	return func(up *smugmug.Uploadable) error {
+		if latitude < -90 || latitude > 90 || longitude < -180 || longitude > 180 {
+			return fmt.Errorf("invalid location {%f, %f}", latitude, longitude)
+		}
+		up.Location = &smugmug.Location{Latitude: latitude, Longitude: longitude, Altitude: altitude}
+		return nil
+	}
+}
+
+// albumFunc returns the key of the album into which the Uploadable is uploaded
+type albumFunc func(fs afero.Fs, up *smugmug.Uploadable) (string, error)
+
 type fsUploadable struct {
-	albumKey string
-	pre      []PreFunc
-	use      []UseFunc
+	album albumFunc
+	pre   []PreFunc
+	use   []UseFunc
 }
 
 // NewFsUploadable returns a newly instantiated FsUploadable instance
@@ -90,7 +196,9 @@ func NewFsUploadable(albumKey string) (FsUploadable, error) {
 	if albumKey == "" {
 		return nil, errors.New("missing albumKey")
 	}
-	return &fsUploadable{albumKey: albumKey}, nil
+	return &fsUploadable{album: func(afero.Fs, *smugmug.Uploadable) (string, error) {
+		return albumKey, nil
+	}}, nil
 }
 
 func (p *fsUploadable) Pre(f ...PreFunc) {
@@ -116,7 +224,10 @@ func (p *fsUploadable) Uploadable(fs afero.Fs, filename string) (*smugmug.Upload
 	if err != nil {
 		return nil, err
 	}
-	up.AlbumKey = p.albumKey
+	up.AlbumKey, err = p.album(fs, up)
+	if err != nil {
+		return nil, err
+	}
 
 	for i := range p.use {
 		err = p.use[i](up)
@@ -135,16 +246,91 @@ func (p *fsUploadable) open(fs afero.Fs, path string) (*smugmug.Uploadable, erro
 	}
 	defer fp.Close()
 
-	var buf bytes.Buffer
-	size, err := io.Copy(&buf, fp)
+	info, err := fp.Stat()
 	if err != nil {
 		return nil, err
 	}
 
-	return &smugmug.Uploadable{
-		Name:   filepath.Base(path),
-		Size:   size,
-		MD5:    fmt.Sprintf("%x", md5.Sum(buf.Bytes())), //nolint:gosec // required for smugmug
-		Reader: &buf,
-	}, nil
+	// hash the file in a streaming pass; the contents are read again from the filesystem when uploaded
+	hash := md5.New() //nolint:gosec // required for smugmug
+	header, err := readHeader(fp)
+	if err != nil {
+		return nil, err
+	}
+	hash.Write(header)
+	size, err := io.Copy(hash, fp)
+	if err != nil {
+		return nil, err
+	}
+
+	size += int64(len(header))
+	up := &smugmug.Uploadable{
+		Name:       filepath.Base(path),
+		Path:       path,
+		ModTime:    info.ModTime(),
+		Size:       size,
+		SourceSize: size,
+		MD5:        fmt.Sprintf("%x", hash.Sum(nil)),
+		Reader:     &fileReader{fs: fs, path: path},
+	}
+	if mt, err := smugmug.DetectMediaType(path, header); err == nil {
+		up.ContentType = mt.ContentType
+	}
+	return up, nil
+}
+
+// readHeader reads the leading bytes of the file used for detecting the media type
+func readHeader(r io.Reader) ([]byte, error) {
+	header := make([]byte, headerLen)
+	n, err := io.ReadFull(r, header)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, err
+	}
+	return header[:n], nil
+}
+
+// fileReader opens the file on first use so an Uploadable does not hold a file handle until uploaded
+type fileReader struct {
+	fs   afero.Fs
+	path string
+	fp   afero.File
+}
+
+func (r *fileReader) file() (afero.File, error) {
+	if r.fp == nil {
+		fp, err := r.fs.Open(r.path)
+		if err != nil {
+			return nil, err
+		}
+		r.fp = fp
+	}
+	return r.fp, nil
+}
+
+// Read reads from the file, opening it if necessary
+func (r *fileReader) Read(b []byte) (int, error) {
+	fp, err := r.file()
+	if err != nil {
+		return 0, err
+	}
+	return fp.Read(b)
+}
+
+// Seek sets the offset for the next Read, opening the file if necessary
+func (r *fileReader) Seek(offset int64, whence int) (int64, error) {
+	fp, err := r.file()
+	if err != nil {
+		return 0, err
+	}
+	return fp.Seek(offset, whence)
+}
+
+// Close closes the file; a subsequent Read or Seek will reopen it
+func (r *fileReader) Close() error {
+	if r.fp == nil {
+		return nil
+	}
+	err := r.fp.Close()
+	r.fp = nil
+	return err
 }
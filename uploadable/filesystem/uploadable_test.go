@@ -1,8 +1,16 @@
 package filesystem_test
 
 import (
+	"bytes"
+	"context"
 	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
@@ -129,6 +137,43 @@ func TestUploadable(t *testing.T) {
 				a.Empty(up.Replaces)
 			},
 		},
+		{
+			name:     "metadata",
+			filename: "DSC12345.jpg",
+			use: func(up *smugmug.Uploadable) error {
+				for _, f := range []filesystem.UseFunc{
+					filesystem.Title("Marmot"),
+					filesystem.Caption("A marmot on a rock"),
+					filesystem.Keywords("marmot", "rock"),
+					filesystem.Keywords("hiking"),
+					filesystem.Hidden(true),
+					filesystem.Geolocation(47.6062, -122.3321, 52.5),
+				} {
+					if err := f(up); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+			f: func(up *smugmug.Uploadable, err error) {
+				a.NoError(err)
+				a.NotNil(up)
+				a.Equal("Marmot", up.Title)
+				a.Equal("A marmot on a rock", up.Caption)
+				a.Equal([]string{"marmot", "rock", "hiking"}, up.Keywords)
+				a.True(up.Hidden)
+				a.Equal(&smugmug.Location{Latitude: 47.6062, Longitude: -122.3321, Altitude: 52.5}, up.Location)
+			},
+		},
+		{
+			name:     "invalid geolocation",
+			filename: "DSC12345.jpg",
+			use:      filesystem.Geolocation(91, 0, 0),
+			f: func(up *smugmug.Uploadable, err error) {
+				a.Error(err)
+				a.Nil(up)
+			},
+		},
 		{
 			name:     "pre function returning error propagates error",
 			filename: "DSC_error.jpg",
@@ -164,6 +209,116 @@ func TestUploadable(t *testing.T) {
 	}
 }
 
+func TestMedia(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		filename     string
+		content      string
+		contentTypes []string
+		ok           bool
+	}{
+		{name: "jpeg", filename: "DSC0001.jpg", content: "\xff\xd8\xff\xe1", ok: true},
+		{name: "jpeg with wrong extension", filename: "DSC0001.txt", content: "\xff\xd8\xff\xe1", ok: true},
+		{name: "text", filename: "DSC0001.jpg", content: "this is a test"},
+		{name: "video only", filename: "DSC0001.jpg", content: "\xff\xd8\xff\xe1", contentTypes: []string{"video/"}},
+		{name: "video", filename: "DSC0001.mp4", content: "\x00\x00\x00\x20ftypisom",
+			contentTypes: []string{"video/"}, ok: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			fs := new(afero.MemMapFs)
+			a.NoError(afero.WriteFile(fs, tt.filename, []byte(tt.content), 0644))
+			ok, err := filesystem.Media(tt.contentTypes...)(fs, tt.filename)
+			a.NoError(err)
+			a.Equal(tt.ok, ok)
+		})
+	}
+
+	ok, err := filesystem.Media()(new(afero.MemMapFs), "missing.jpg")
+	assert.Error(t, err)
+	assert.False(t, ok)
+}
+
+func TestUploadableContentType(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	fs := new(afero.MemMapFs)
+	a.NoError(afero.WriteFile(fs, "DSC0001.jpg", []byte("\xff\xd8\xff\xe1"+strings.Repeat("a", 1024)), 0644))
+	a.NoError(afero.WriteFile(fs, "DSC0002.jpg", []byte("this is a test"), 0644))
+	fsup, err := filesystem.NewFsUploadable("albumKey")
+	a.NoError(err)
+
+	up, err := fsup.Uploadable(fs, "DSC0001.jpg")
+	a.NoError(err)
+	a.Equal("image/jpeg", up.ContentType)
+	a.Equal(int64(1028), up.Size)
+
+	up, err = fsup.Uploadable(fs, "DSC0002.jpg")
+	a.NoError(err)
+	a.Empty(up.ContentType)
+	a.Equal(int64(14), up.Size)
+}
+
+func TestJournaled(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	fs := new(afero.MemMapFs)
+	a.NoError(afero.WriteFile(fs, "DSC0001.jpg", []byte("this is a test"), 0644))
+	a.NoError(afero.WriteFile(fs, "DSC0002.jpg", []byte("this is a test"), 0644))
+	fsup, err := filesystem.NewFsUploadable("albumKey")
+	a.NoError(err)
+
+	var buf bytes.Buffer
+	j, err := smugmug.NewJournal(&buf)
+	a.NoError(err)
+
+	up, err := fsup.Uploadable(fs, "DSC0001.jpg")
+	a.NoError(err)
+	a.Equal("DSC0001.jpg", up.Path)
+	a.False(up.ModTime.IsZero())
+	a.NoError(j.Record(&smugmug.Upload{Uploadable: up}))
+
+	fsup.Pre(filesystem.Journaled(j))
+	up, err = fsup.Uploadable(fs, "DSC0001.jpg")
+	a.ErrorIs(err, filesystem.ErrSkip)
+	a.Nil(up)
+	up, err = fsup.Uploadable(fs, "DSC0002.jpg")
+	a.NoError(err)
+	a.NotNil(up)
+
+	// a file rewritten before uploading is matched by the size of the file
+	rewrite, err := filesystem.NewFsUploadable("albumKey")
+	a.NoError(err)
+	rewrite.Use(func(up *smugmug.Uploadable) error {
+		up.Size = 4
+		return nil
+	})
+	up, err = rewrite.Uploadable(fs, "DSC0002.jpg")
+	a.NoError(err)
+	a.Equal(int64(14), up.SourceSize)
+	a.NoError(j.Record(&smugmug.Upload{Uploadable: up}))
+	up, err = fsup.Uploadable(fs, "DSC0002.jpg")
+	a.ErrorIs(err, filesystem.ErrSkip)
+	a.Nil(up)
+
+	// a modified file is uploaded again
+	a.NoError(afero.WriteFile(fs, "DSC0001.jpg", []byte("this is a modified test"), 0644))
+	up, err = fsup.Uploadable(fs, "DSC0001.jpg")
+	a.NoError(err)
+	a.NotNil(up)
+
+	up, err = fsup.Uploadable(fs, "DSC0003.jpg")
+	a.Error(err)
+	a.Nil(up)
+}
+
 func TestOpenError(t *testing.T) {
 	t.Parallel()
 	a := assert.New(t)
@@ -178,3 +333,79 @@ func TestOpenError(t *testing.T) {
 	a.Error(err)
 	a.Nil(up)
 }
+
+func TestUploadableReader(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	fs := new(afero.MemMapFs)
+	a.NoError(afero.WriteFile(fs, "DSC1234.jpg", []byte("this is a test"), 0644))
+	fsup, err := filesystem.NewFsUploadable("albumKey")
+	a.NoError(err)
+
+	up, err := fsup.Uploadable(fs, "DSC1234.jpg")
+	a.NoError(err)
+	a.Equal(int64(14), up.Size)
+	a.Equal("54b0c58c7ce9f2a8b551351102ee0938", up.MD5)
+
+	rsc, ok := up.Reader.(io.ReadSeekCloser)
+	a.True(ok)
+	b, err := io.ReadAll(rsc)
+	a.NoError(err)
+	a.Equal("this is a test", string(b))
+	a.NoError(rsc.Close())
+	a.NoError(rsc.Close())
+
+	// the file is reopened after being closed
+	n, err := rsc.Seek(5, io.SeekStart)
+	a.NoError(err)
+	a.Equal(int64(5), n)
+	b, err = io.ReadAll(rsc)
+	a.NoError(err)
+	a.Equal("is a test", string(b))
+	a.NoError(rsc.Close())
+
+	a.NoError(fs.Remove("DSC1234.jpg"))
+	_, err = rsc.Read(b)
+	a.Error(err)
+	_, err = rsc.Seek(0, io.SeekStart)
+	a.Error(err)
+}
+
+func TestUploadableRetry(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	data := bytes.Repeat([]byte("this is a test"), 1<<20)
+
+	var attempts atomic.Int32
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) <= 2 {
+			// respond before reading the body so the transport is still sending it
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.(http.Flusher).Flush()
+			time.Sleep(10 * time.Millisecond)
+			return
+		}
+		b, err := io.ReadAll(r.Body)
+		a.NoError(err)
+		a.Equal(len(data), len(b))
+		http.ServeFile(w, r, "testdata/upload_CVvj69L.json")
+	}))
+	defer svr.Close()
+
+	mg, err := smugmug.NewClient(smugmug.WithUploadURL(svr.URL), smugmug.WithRetries(2, time.Millisecond))
+	a.NoError(err)
+
+	fs := new(afero.MemMapFs)
+	a.NoError(afero.WriteFile(fs, "DSC1234.jpg", data, 0644))
+	fsup, err := filesystem.NewFsUploadable("albumKey")
+	a.NoError(err)
+	up, err := fsup.Uploadable(fs, "DSC1234.jpg")
+	a.NoError(err)
+
+	upload, err := mg.Upload.Upload(context.TODO(), up)
+	a.NoError(err)
+	a.NotNil(upload)
+	a.Equal(int32(3), attempts.Load())
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"io/fs"
+	"path/filepath"
 
 	"github.com/spf13/afero"
 	"golang.org/x/sync/errgroup"
@@ -11,16 +12,22 @@ import (
 	"github.com/bzimmer/smugmug"
 )
 
+// walker calls `fn` with the path of each file found under `root`
+type walker func(root string, fn func(string) error) error
+
 type fsUploadables struct {
 	fs         afero.Fs
 	filenames  []string
 	uploadable FsUploadable
+	walker     walker
+	// skipped sends files skipped by the FsUploadable as Uploadables with Skipped set rather than dropping them
+	skipped bool
 }
 
 // NewFsUploadables returns a new instance of an Uploadables which creates Uploadable instances
 // from files on the filesystem
 func NewFsUploadables(afs afero.Fs, filenames []string, uploadable FsUploadable) smugmug.Uploadables {
-	return &fsUploadables{fs: afs, filenames: filenames, uploadable: uploadable}
+	return &fsUploadables{fs: afs, filenames: filenames, uploadable: uploadable, walker: aferoWalker(afs)}
 }
 
 func (p *fsUploadables) Uploadables(ctx context.Context) (<-chan *smugmug.Uploadable, <-chan error) { //nolint:gocognit
@@ -49,10 +56,13 @@ func (p *fsUploadables) Uploadables(ctx context.Context) (<-chan *smugmug.Upload
 				}
 				up, err := p.uploadable.Uploadable(p.fs, filename)
 				if err != nil {
-					if errors.Is(err, ErrSkip) {
+					if !errors.Is(err, ErrSkip) {
+						return err
+					}
+					if !p.skipped {
 						continue
 					}
-					return err
+					up = &smugmug.Uploadable{Name: filepath.Base(filename), Path: filename, Skipped: err}
 				}
 				select {
 				case <-ctx.Done():
@@ -81,13 +91,7 @@ func (p *fsUploadables) walk(ctx context.Context) (<-chan string, <-chan error)
 		defer close(errc)
 		defer close(filenamesc)
 		for _, root := range p.filenames {
-			if err := afero.Walk(p.fs, root, func(path string, info fs.FileInfo, err error) error {
-				if err != nil {
-					return err
-				}
-				if info.IsDir() {
-					return nil
-				}
+			if err := p.walker(root, func(path string) error {
 				select {
 				case <-ctx.Done():
 					return ctx.Err()
@@ -101,3 +105,29 @@ func (p *fsUploadables) walk(ctx context.Context) (<-chan string, <-chan error)
 	}()
 	return filenamesc, errc
 }
+
+// aferoWalker walks the afero filesystem skipping files matched by an IgnoreFile
+func aferoWalker(afs afero.Fs) walker {
+	return func(root string, fn func(string) error) error {
+		ignores := newIgnores(func(name string) ([]byte, error) { return afero.ReadFile(afs, name) })
+		return afero.Walk(afs, root, func(path string, info fs.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				ok, err := ignores.enter(path)
+				if err != nil {
+					return err
+				}
+				if !ok {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !ignores.accept(path) {
+				return nil
+			}
+			return fn(path)
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package gpx
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/bzimmer/smugmug"
+)
+
+// DefaultTolerance is the tolerance used when a Geotagger does not specify one
+const DefaultTolerance = 5 * time.Minute
+
+// Geotagger matches the capture time of images to a track
+type Geotagger struct {
+	// Track is the recorded positions
+	Track *Track
+	// Offset is the amount the camera clock was ahead of the actual time, eg a camera five minutes
+	// fast has an offset of five minutes
+	Offset time.Duration
+	// Location is the time zone to which the camera clock was set; if nil the zone of the capture time is used
+	Location *time.Location
+	// Tolerance is the maximum time from the nearest recorded point, DefaultTolerance if zero
+	Tolerance time.Duration
+}
+
+// Time returns the actual time of the camera clock reading `taken`
+func (g *Geotagger) Time(taken time.Time) time.Time {
+	if g.Location != nil {
+		taken = time.Date(taken.Year(), taken.Month(), taken.Day(),
+			taken.Hour(), taken.Minute(), taken.Second(), taken.Nanosecond(), g.Location)
+	}
+	return taken.Add(-g.Offset)
+}
+
+// Locate returns the position at which the image captured at the camera clock reading `taken` was taken
+func (g *Geotagger) Locate(taken time.Time) (Point, bool) {
+	tolerance := g.Tolerance
+	if tolerance == 0 {
+		tolerance = DefaultTolerance
+	}
+	return g.Track.Locate(g.Time(taken), tolerance)
+}
+
+// Patch geotags the images of an album using their DateTimeOriginal and returns the updated images
+// Images already geotagged are updated only if `force` is true
+func (g *Geotagger) Patch(
+	ctx context.Context, client *smugmug.Client, albumKey string, force bool) ([]*smugmug.Image, error) {
+	var images []*smugmug.Image
+	if err := client.Image.ImagesIter(ctx, albumKey, func(image *smugmug.Image) (bool, error) {
+		if image.DateTimeOriginal == nil || (!force && (image.Latitude != 0 || image.Longitude != 0)) {
+			return true, nil
+		}
+		pt, ok := g.Locate(*image.DateTimeOriginal)
+		if !ok {
+			return true, nil
+		}
+		patched, err := client.Image.Patch(ctx, image.ImageKey, map[string]any{
+			"Latitude":  pt.Latitude,
+			"Longitude": pt.Longitude,
+			"Altitude":  int(math.Round(pt.Elevation)),
+		})
+		if err != nil {
+			return false, err
+		}
+		images = append(images, patched)
+		return true, nil
+	}); err != nil {
+		return nil, err
+	}
+	return images, nil
+}
@@ -0,0 +1,82 @@
+package gpx_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bzimmer/smugmug"
+	"github.com/bzimmer/smugmug/uploadable/gpx"
+)
+
+func TestGeotaggerTime(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	pst := time.FixedZone("PST", -8*3600)
+	taken := time.Date(2024, time.March, 4, 1, 17, 12, 0, time.UTC)
+
+	g := &gpx.Geotagger{Offset: 5 * time.Minute, Location: pst}
+	a.True(time.Date(2024, time.March, 4, 9, 12, 12, 0, time.UTC).Equal(g.Time(taken)))
+
+	g = &gpx.Geotagger{}
+	a.True(taken.Equal(g.Time(taken)))
+}
+
+func TestGeotaggerPatch(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	// the camera clock was set to the local time of -08:00 and was two minutes slow
+	taken := func(s string) *time.Time {
+		t, err := time.Parse(time.DateTime, s)
+		a.NoError(err)
+		return &t
+	}
+	images := []*smugmug.Image{
+		{ImageKey: "a", DateTimeOriginal: taken("2024-03-04 01:09:12")},
+		{ImageKey: "b", DateTimeOriginal: taken("2024-03-04 01:09:12"), Latitude: 1, Longitude: 1},
+		{ImageKey: "c"},
+		{ImageKey: "d", DateTimeOriginal: taken("2024-03-04 05:00:00")},
+	}
+
+	patched := make(map[string]map[string]any)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /album/{albumKey}", func(w http.ResponseWriter, r *http.Request) {
+		a.Equal("QpLn7s!images", r.PathValue("albumKey"))
+		a.NoError(json.NewEncoder(w).Encode(map[string]any{"Response": map[string]any{
+			"AlbumImage": images, "Pages": smugmug.Pages{Total: len(images), Start: 1, Count: len(images)}}}))
+	})
+	mux.HandleFunc("PATCH /image/{imageKey}", func(w http.ResponseWriter, r *http.Request) {
+		data := make(map[string]any)
+		a.NoError(json.NewDecoder(r.Body).Decode(&data))
+		patched[r.PathValue("imageKey")] = data
+		a.NoError(json.NewEncoder(w).Encode(map[string]any{"Response": map[string]any{
+			"Image": smugmug.Image{ImageKey: r.PathValue("imageKey")}}}))
+	})
+	svr := httptest.NewServer(mux)
+	defer svr.Close()
+
+	mg, err := smugmug.NewClient(smugmug.WithBaseURL(svr.URL))
+	a.NoError(err)
+
+	g := &gpx.Geotagger{Track: load(t), Offset: -2 * time.Minute, Location: time.FixedZone("", -8*3600)}
+	updated, err := g.Patch(context.TODO(), mg, "QpLn7s", false)
+	a.NoError(err)
+	a.Len(updated, 1)
+	a.Equal("a", updated[0].ImageKey)
+	a.Len(patched, 1)
+	a.InDelta(47.606, patched["a"]["Latitude"], 1e-9)
+	a.InDelta(-122.312, patched["a"]["Longitude"], 1e-9)
+	a.InDelta(160, patched["a"]["Altitude"], 1e-9)
+
+	updated, err = g.Patch(context.TODO(), mg, "QpLn7s", true)
+	a.NoError(err)
+	a.Len(updated, 2)
+	a.Contains(patched, "b")
+}
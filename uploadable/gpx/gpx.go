@@ -0,0 +1,129 @@
+package gpx
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// Point is a recorded position of a track
+type Point struct {
+	// Time is the time the position was recorded
+	Time time.Time
+	// Latitude in decimal degrees
+	Latitude float64
+	// Longitude in decimal degrees
+	Longitude float64
+	// Elevation in meters
+	Elevation float64
+}
+
+// Track is the time ordered positions of one or more GPX tracks
+type Track struct {
+	Points []Point
+}
+
+type document struct {
+	Points []struct {
+		Latitude  float64 `xml:"lat,attr"`
+		Longitude float64 `xml:"lon,attr"`
+		Elevation float64 `xml:"ele"`
+		Time      string  `xml:"time"`
+	} `xml:"trk>trkseg>trkpt"`
+}
+
+// Decode reads the track points of a GPX document; points without a time are ignored
+func Decode(r io.Reader) (*Track, error) {
+	var doc document
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+	track := &Track{Points: make([]Point, 0, len(doc.Points))}
+	for _, pt := range doc.Points {
+		if pt.Time == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, pt.Time)
+		if err != nil {
+			return nil, err
+		}
+		if pt.Latitude < -90 || pt.Latitude > 90 || pt.Longitude < -180 || pt.Longitude > 180 {
+			return nil, fmt.Errorf("invalid location {%f, %f}", pt.Latitude, pt.Longitude)
+		}
+		track.Points = append(track.Points,
+			Point{Time: t, Latitude: pt.Latitude, Longitude: pt.Longitude, Elevation: pt.Elevation})
+	}
+	return Merge(track), nil
+}
+
+// Load reads and merges the GPX files
+func Load(afs afero.Fs, filenames ...string) (*Track, error) {
+	tracks := make([]*Track, 0, len(filenames))
+	for _, filename := range filenames {
+		track, err := load(afs, filename)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filename, err)
+		}
+		tracks = append(tracks, track)
+	}
+	return Merge(tracks...), nil
+}
+
+func load(afs afero.Fs, filename string) (*Track, error) {
+	fp, err := afs.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer fp.Close()
+	return Decode(fp)
+}
+
+// Merge returns a single track of the points of all tracks ordered by time
+func Merge(tracks ...*Track) *Track {
+	var points []Point
+	for _, track := range tracks {
+		points = append(points, track.Points...)
+	}
+	slices.SortStableFunc(points, func(a, b Point) int {
+		return a.Time.Compare(b.Time)
+	})
+	return &Track{Points: points}
+}
+
+// Locate returns the position at time `at` interpolated from the recorded points
+// No position is found if `at` is further than `tolerance` from the nearest recorded point
+func (t *Track) Locate(at time.Time, tolerance time.Duration) (Point, bool) {
+	n := len(t.Points)
+	i := sort.Search(n, func(i int) bool {
+		return !t.Points[i].Time.Before(at)
+	})
+	switch {
+	case n == 0:
+		return Point{}, false
+	case i == 0:
+		return t.Points[0], t.Points[0].Time.Sub(at) <= tolerance
+	case i == n:
+		return t.Points[n-1], at.Sub(t.Points[n-1].Time) <= tolerance
+	}
+
+	p0, p1 := t.Points[i-1], t.Points[i]
+	if at.Sub(p0.Time) > tolerance && p1.Time.Sub(at) > tolerance {
+		return Point{}, false
+	}
+	span := p1.Time.Sub(p0.Time)
+	if span == 0 {
+		return p1, true
+	}
+	f := float64(at.Sub(p0.Time)) / float64(span)
+	return Point{
+		Time:      at,
+		Latitude:  p0.Latitude + f*(p1.Latitude-p0.Latitude),
+		Longitude: p0.Longitude + f*(p1.Longitude-p0.Longitude),
+		Elevation: p0.Elevation + f*(p1.Elevation-p0.Elevation),
+	}, true
+}
@@ -0,0 +1,134 @@
+package gpx_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bzimmer/smugmug/uploadable/gpx"
+)
+
+func load(t *testing.T) *gpx.Track {
+	t.Helper()
+	track, err := gpx.Load(afero.NewOsFs(), "testdata/hike.gpx", "testdata/later.gpx")
+	assert.NoError(t, err)
+	return track
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	track := load(t)
+	a.Len(track.Points, 4)
+	for i := 1; i < len(track.Points); i++ {
+		a.True(track.Points[i-1].Time.Before(track.Points[i].Time))
+	}
+	a.InDelta(47.65, track.Points[2].Latitude, 1e-9)
+
+	track, err := gpx.Load(afero.NewOsFs(), "testdata/missing.gpx")
+	a.Error(err)
+	a.Nil(track)
+}
+
+func TestDecode(t *testing.T) {
+	t.Parallel()
+
+	for name, doc := range map[string]string{
+		"malformed": `<gpx><trk>`,
+		"time":      `<gpx><trk><trkseg><trkpt lat="1" lon="2"><time>yesterday</time></trkpt></trkseg></trk></gpx>`,
+		"location":  `<gpx><trk><trkseg><trkpt lat="91" lon="2"><time>2024-03-04T09:10:00Z</time></trkpt></trkseg></trk></gpx>`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			track, err := gpx.Decode(strings.NewReader(doc))
+			a.Error(err)
+			a.Nil(track)
+		})
+	}
+}
+
+func TestLocate(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, time.March, 4, 9, 10, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		at        time.Time
+		ok        bool
+		latitude  float64
+		longitude float64
+		elevation float64
+	}{
+		{
+			name:      "interpolated",
+			at:        start.Add(72 * time.Second),
+			ok:        true,
+			latitude:  47.606,
+			longitude: -122.312,
+			elevation: 160,
+		},
+		{
+			name:      "exact",
+			at:        start,
+			ok:        true,
+			latitude:  47.6,
+			longitude: -122.3,
+			elevation: 100,
+		},
+		{
+			name:      "before start within tolerance",
+			at:        start.Add(-time.Minute),
+			ok:        true,
+			latitude:  47.6,
+			longitude: -122.3,
+			elevation: 100,
+		},
+		{
+			name: "before start",
+			at:   start.Add(-time.Hour),
+		},
+		{
+			name:      "after end within tolerance",
+			at:        start.Add(time.Hour + 52*time.Minute),
+			ok:        true,
+			latitude:  47.7,
+			longitude: -122.4,
+			elevation: 300,
+		},
+		{
+			name: "after end",
+			at:   start.Add(3 * time.Hour),
+		},
+		{
+			name: "gap",
+			at:   start.Add(30 * time.Minute),
+		},
+	}
+
+	track := load(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			pt, ok := track.Locate(tt.at, 5*time.Minute)
+			a.Equal(tt.ok, ok)
+			if !tt.ok {
+				return
+			}
+			a.InDelta(tt.latitude, pt.Latitude, 1e-9)
+			a.InDelta(tt.longitude, pt.Longitude, 1e-9)
+			a.InDelta(tt.elevation, pt.Elevation, 1e-9)
+		})
+	}
+
+	var empty gpx.Track
+	_, ok := empty.Locate(start, time.Hour)
+	assert.False(t, ok)
+}
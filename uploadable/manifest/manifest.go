@@ -0,0 +1,271 @@
+package manifest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// Format is the encoding of a manifest
+type Format string
+
+const (
+	// CSV is a comma separated manifest with a header row naming the columns
+	CSV Format = "csv"
+	// JSON is a manifest of a single array of entries
+	JSON Format = "json"
+	// JSONL is a manifest of one entry per line
+	JSONL Format = "jsonl"
+
+	// keywordSeparator separates the keywords of a CSV entry
+	keywordSeparator = ";"
+	// imagePrefix is the prefix of the uri of an image to replace
+	imagePrefix = "/api/v2/image/"
+)
+
+// Entry is a single file to upload
+type Entry struct {
+	// Line is the line of the manifest on which the entry starts
+	Line int `json:"-"`
+	// Path is the location of the file
+	Path string `json:"Path"`
+	// AlbumKey is the album into which the file will be uploaded
+	AlbumKey string `json:"AlbumKey"`
+	// Album is the path of folders and album names into which the file will be uploaded if no AlbumKey is specified
+	Album string `json:"Album"`
+	// Title is the title of the image
+	Title string `json:"Title"`
+	// Caption is the caption of the image
+	Caption string `json:"Caption"`
+	// Keywords are the keywords of the image
+	Keywords []string `json:"Keywords"`
+	// Replaces is the URI of an image to replace
+	Replaces string `json:"Replaces"`
+
+	// err is the error reading the entry
+	err error
+}
+
+// LineError reports an invalid entry of a manifest
+type LineError struct {
+	Line int
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Err)
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// FormatOf returns the format of the manifest from the extension of its filename
+func FormatOf(filename string) (Format, error) {
+	switch ext := strings.ToLower(filepath.Ext(filename)); ext {
+	case ".csv":
+		return CSV, nil
+	case ".json":
+		return JSON, nil
+	case ".jsonl", ".ndjson":
+		return JSONL, nil
+	default:
+		return "", fmt.Errorf("unknown manifest format `%s`", ext)
+	}
+}
+
+// Open reads the manifest in the format of its extension
+func Open(afs afero.Fs, filename string) ([]*Entry, error) {
+	format, err := FormatOf(filename)
+	if err != nil {
+		return nil, err
+	}
+	fp, err := afs.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer fp.Close()
+	return Read(fp, format)
+}
+
+// Read reads and validates the entries of the manifest
+// All invalid entries are reported, each as a LineError
+func Read(r io.Reader, format Format) ([]*Entry, error) {
+	var (
+		entries []*Entry
+		err     error
+	)
+	switch format {
+	case CSV:
+		entries, err = readCSV(r)
+	case JSON:
+		entries, err = readJSON(r)
+	case JSONL:
+		entries, err = readJSONL(r)
+	default:
+		return nil, fmt.Errorf("unknown manifest format `%s`", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var errs []error
+	for _, entry := range entries {
+		if err := entry.validate(); err != nil {
+			errs = append(errs, &LineError{Line: entry.Line, Err: err})
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return entries, nil
+}
+
+// validate checks the entry has the required fields
+func (e *Entry) validate() error {
+	if e.err != nil {
+		return e.err
+	}
+	var keywords []string
+	for _, keyword := range e.Keywords {
+		if keyword = strings.TrimSpace(keyword); keyword != "" {
+			keywords = append(keywords, keyword)
+		}
+	}
+	e.Keywords = keywords
+	switch {
+	case e.Path == "":
+		return errors.New("missing path")
+	case e.AlbumKey == "" && e.Album == "":
+		return errors.New("missing album key or album")
+	case e.AlbumKey != "" && e.Album != "":
+		return errors.New("only one of album key or album may be specified")
+	case e.Replaces != "" && !strings.HasPrefix(e.Replaces, imagePrefix):
+		return fmt.Errorf("invalid image uri `%s`", e.Replaces)
+	}
+	return nil
+}
+
+func readCSV(r io.Reader) ([]*Entry, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	columns := make([]func(*Entry, string), len(header))
+	for i, name := range header {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "path":
+			columns[i] = func(e *Entry, s string) { e.Path = s }
+		case "albumkey":
+			columns[i] = func(e *Entry, s string) { e.AlbumKey = s }
+		case "album":
+			columns[i] = func(e *Entry, s string) { e.Album = s }
+		case "title":
+			columns[i] = func(e *Entry, s string) { e.Title = s }
+		case "caption":
+			columns[i] = func(e *Entry, s string) { e.Caption = s }
+		case "keywords":
+			columns[i] = func(e *Entry, s string) { e.Keywords = strings.Split(s, keywordSeparator) }
+		case "replaces":
+			columns[i] = func(e *Entry, s string) { e.Replaces = s }
+		default:
+			return nil, &LineError{Line: 1, Err: fmt.Errorf("unknown column `%s`", name)}
+		}
+	}
+
+	var entries []*Entry
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		entry := &Entry{Line: line}
+		if len(record) != len(header) {
+			entry.err = fmt.Errorf("expected %d fields but found %d", len(header), len(record))
+			entries = append(entries, entry)
+			continue
+		}
+		for i, value := range record {
+			columns[i](entry, strings.TrimSpace(value))
+		}
+		entries = append(entries, entry)
+	}
+}
+
+func readJSON(r io.Reader) ([]*Entry, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+		return nil, &LineError{Line: lineOf(data, dec.InputOffset()), Err: errors.New("expected an array of entries")}
+	}
+	var entries []*Entry
+	for dec.More() {
+		line := lineOf(data, dec.InputOffset())
+		entry, err := decode(dec)
+		if err != nil {
+			return nil, &LineError{Line: line, Err: err}
+		}
+		entry.Line = line
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func readJSONL(r io.Reader) ([]*Entry, error) {
+	var entries []*Entry
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		entry, err := decode(json.NewDecoder(bytes.NewReader(text)))
+		if err != nil {
+			entry = &Entry{err: err}
+		}
+		entry.Line = line
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// decode decodes the next entry rejecting unknown fields
+func decode(dec *json.Decoder) (*Entry, error) {
+	dec.DisallowUnknownFields()
+	entry := &Entry{}
+	if err := dec.Decode(entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// lineOf returns the line of the first entry following `offset`, skipping whitespace and separators
+func lineOf(data []byte, offset int64) int {
+	i := int(offset)
+	for i < len(data) && strings.ContainsRune(" \t\r\n,", rune(data[i])) {
+		i++
+	}
+	return 1 + bytes.Count(data[:min(i, len(data))], []byte("\n"))
+}
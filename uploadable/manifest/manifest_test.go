@@ -0,0 +1,185 @@
+package manifest_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bzimmer/smugmug/uploadable/manifest"
+)
+
+func TestFormatOf(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	for filename, format := range map[string]manifest.Format{
+		"uploads.csv":    manifest.CSV,
+		"uploads.JSON":   manifest.JSON,
+		"uploads.jsonl":  manifest.JSONL,
+		"uploads.ndjson": manifest.JSONL,
+	} {
+		f, err := manifest.FormatOf(filename)
+		a.NoError(err)
+		a.Equal(format, f)
+	}
+	f, err := manifest.FormatOf("uploads.txt")
+	a.Error(err)
+	a.Empty(f)
+}
+
+func TestOpen(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		filename string
+		lines    []int
+	}{
+		{filename: "testdata/manifest.csv", lines: []int{2, 3}},
+		{filename: "testdata/manifest.json", lines: []int{2, 9}},
+		{filename: "testdata/manifest.jsonl", lines: []int{1, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filename, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			entries, err := manifest.Open(afero.NewOsFs(), tt.filename)
+			a.NoError(err)
+			a.Len(entries, 2)
+			a.Equal(tt.lines, []int{entries[0].Line, entries[1].Line})
+
+			a.Equal("photos/DSC0001.jpg", entries[0].Path)
+			a.Equal("7dFHSm", entries[0].AlbumKey)
+			a.Equal("Marmot", entries[0].Title)
+			a.Equal("A marmot, sunning on a rock", entries[0].Caption)
+			a.Equal([]string{"marmot", "hiking"}, entries[0].Keywords)
+			a.Empty(entries[0].Replaces)
+
+			a.Equal("/Misc", entries[1].Album)
+			a.Empty(entries[1].AlbumKey)
+			a.Empty(entries[1].Keywords)
+			a.Equal("/api/v2/image/CVvj69L-0", entries[1].Replaces)
+		})
+	}
+
+	entries, err := manifest.Open(afero.NewOsFs(), "testdata/missing.csv")
+	assert.Error(t, err)
+	assert.Nil(t, entries)
+}
+
+func TestRead(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		format   manifest.Format
+		manifest string
+		lines    []int
+		err      string
+	}{
+		{
+			name:   "csv",
+			format: manifest.CSV,
+			manifest: `path,albumkey,album,replaces
+,7dFHSm,,
+a.jpg,,,
+a.jpg,7dFHSm,/Misc,
+a.jpg,7dFHSm
+a.jpg,7dFHSm,,/api/v2/album/7dFHSm
+a.jpg,7dFHSm,,
+`,
+			lines: []int{2, 3, 4, 5, 6},
+			err:   "missing path",
+		},
+		{
+			name:     "csv column",
+			format:   manifest.CSV,
+			manifest: "path,album_key\na.jpg,7dFHSm\n",
+			lines:    []int{1},
+			err:      "unknown column `album_key`",
+		},
+		{
+			name:     "csv empty",
+			format:   manifest.CSV,
+			manifest: "",
+		},
+		{
+			name:   "jsonl",
+			format: manifest.JSONL,
+			manifest: `{"Path": "a.jpg", "AlbumKey": "7dFHSm"}
+{"Path": "a.jpg", "AlbumKey": "7dFHSm", "Unknown": true}
+{"Path": "a.jpg"
+{"AlbumKey": "7dFHSm"}
+`,
+			lines: []int{2, 3, 4},
+			err:   "unknown field",
+		},
+		{
+			name:   "json",
+			format: manifest.JSON,
+			manifest: `[
+  {"Path": "a.jpg", "AlbumKey": "7dFHSm"},
+
+  {"Path": "a.jpg", "AlbumKey": 7}
+]`,
+			lines: []int{4},
+			err:   "cannot unmarshal",
+		},
+		{
+			name:     "json object",
+			format:   manifest.JSON,
+			manifest: `{"Path": "a.jpg", "AlbumKey": "7dFHSm"}`,
+			lines:    []int{1},
+			err:      "expected an array",
+		},
+		{
+			name:     "json validation",
+			format:   manifest.JSON,
+			manifest: "[\n{\"Path\": \"a.jpg\"}\n]",
+			lines:    []int{2},
+			err:      "missing album key or album",
+		},
+		{
+			name:     "format",
+			format:   manifest.Format("xml"),
+			manifest: "<xml/>",
+			err:      "unknown manifest format",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			entries, err := manifest.Read(strings.NewReader(tt.manifest), tt.format)
+			if tt.err == "" {
+				a.NoError(err)
+				a.Empty(entries)
+				return
+			}
+			a.ErrorContains(err, tt.err)
+			a.Nil(entries)
+
+			var lines []int
+			var joined interface{ Unwrap() []error }
+			errs := []error{err}
+			if errors.As(err, &joined) {
+				errs = joined.Unwrap()
+			}
+			for _, err := range errs {
+				var lerr *manifest.LineError
+				if errors.As(err, &lerr) {
+					lines = append(lines, lerr.Line)
+				}
+			}
+			if len(tt.lines) > 0 {
+				a.Equal(tt.lines, lines)
+			}
+		})
+	}
+}
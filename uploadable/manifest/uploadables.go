@@ -0,0 +1,110 @@
+package manifest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/afero"
+
+	"github.com/bzimmer/smugmug"
+	"github.com/bzimmer/smugmug/uploadable/filesystem"
+)
+
+type manifestUploadables struct {
+	fs       afero.Fs
+	entries  []*Entry
+	resolver *filesystem.AlbumResolver
+	use      []filesystem.UseFunc
+}
+
+// NewUploadables returns an Uploadables creating an Uploadable for each entry of the manifest
+// The files of all entries are checked to exist, and the album paths to be valid, before any Uploadable
+// is created
+// The `resolver` finds the album of entries specifying an album path and is not required if all
+// entries specify an album key; missing folders and albums are created only when a file is uploaded
+// The title, caption, keywords and replaced image of the entry are set before the UseFuncs are applied
+func NewUploadables(
+	afs afero.Fs, entries []*Entry, resolver *filesystem.AlbumResolver, use ...filesystem.UseFunc) smugmug.Uploadables {
+	return &manifestUploadables{fs: afs, entries: entries, resolver: resolver, use: use}
+}
+
+func (p *manifestUploadables) Uploadables(ctx context.Context) (<-chan *smugmug.Uploadable, <-chan error) {
+	errc := make(chan error, 1)
+	uploadablesc := make(chan *smugmug.Uploadable)
+	go func() {
+		defer close(errc)
+		defer close(uploadablesc)
+		if err := p.validate(ctx); err != nil {
+			errc <- err
+			return
+		}
+		for _, entry := range p.entries {
+			up, err := p.uploadable(ctx, entry)
+			if err != nil {
+				if errors.Is(err, filesystem.ErrSkip) {
+					continue
+				}
+				errc <- &LineError{Line: entry.Line, Err: err}
+				return
+			}
+			select {
+			case <-ctx.Done():
+				errc <- ctx.Err()
+				return
+			case uploadablesc <- up:
+			}
+		}
+	}()
+	return uploadablesc, errc
+}
+
+// validate checks the file of each entry exists and the album path of each entry can be resolved
+// Nothing is created; an album which does not exist yet is created when its first file is uploaded
+func (p *manifestUploadables) validate(ctx context.Context) error {
+	var errs []error
+	for _, entry := range p.entries {
+		info, err := p.fs.Stat(entry.Path)
+		switch {
+		case err != nil:
+			errs = append(errs, &LineError{Line: entry.Line, Err: err})
+		case !info.Mode().IsRegular():
+			errs = append(errs, &LineError{Line: entry.Line, Err: fmt.Errorf("`%s` is not a file", entry.Path)})
+		}
+		if entry.AlbumKey != "" {
+			continue
+		}
+		if p.resolver == nil {
+			errs = append(errs, &LineError{Line: entry.Line, Err: errors.New("album paths require a resolver")})
+			continue
+		}
+		if _, _, err = p.resolver.Lookup(ctx, entry.Album); err != nil {
+			errs = append(errs, &LineError{Line: entry.Line, Err: err})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (p *manifestUploadables) uploadable(ctx context.Context, entry *Entry) (*smugmug.Uploadable, error) {
+	var (
+		fsu filesystem.FsUploadable
+		err error
+	)
+	if entry.AlbumKey != "" {
+		fsu, err = filesystem.NewFsUploadable(entry.AlbumKey)
+	} else {
+		fsu, err = filesystem.NewAlbumPathUploadable(ctx, p.resolver, entry.Album)
+	}
+	if err != nil {
+		return nil, err
+	}
+	fsu.Use(func(up *smugmug.Uploadable) error {
+		up.Title = entry.Title
+		up.Caption = entry.Caption
+		up.Keywords = append(up.Keywords, entry.Keywords...)
+		up.Replaces = entry.Replaces
+		return nil
+	})
+	fsu.Use(p.use...)
+	return fsu.Uploadable(p.fs, entry.Path)
+}
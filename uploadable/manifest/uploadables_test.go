@@ -0,0 +1,168 @@
+package manifest_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bzimmer/smugmug"
+	"github.com/bzimmer/smugmug/uploadable/filesystem"
+	"github.com/bzimmer/smugmug/uploadable/manifest"
+)
+
+// server returns a server with the album `Misc` in the user's root node which accepts uploads
+func server(t *testing.T, headers *sync.Map) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /!authuser", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"Response":{"User":{"Uris":{"Node":{"Uri":"/api/v2/node/root"}}}}}`))
+	})
+	mux.HandleFunc("GET /node/root!children", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"Response":{"Node":[{"Type":"Album","Name":"Misc","UrlName":"Misc","NodeID":"n2",` +
+			`"Uris":{"Album":{"Uri":"/api/v2/album/a2"}}}],"Pages":{"Total":1,"Start":1,"Count":1}}}`))
+	})
+	mux.HandleFunc("PUT /{$}", func(w http.ResponseWriter, r *http.Request) {
+		name, err := url.PathUnescape(r.Header.Get("X-Smug-FileName"))
+		assert.NoError(t, err)
+		headers.Store(name, r.Header.Clone())
+		http.ServeFile(w, r, "testdata/upload_CVvj69L.json")
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestUploadables(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	var headers sync.Map
+	svr := server(t, &headers)
+	defer svr.Close()
+
+	mg, err := smugmug.NewClient(smugmug.WithBaseURL(svr.URL), smugmug.WithUploadURL(svr.URL))
+	a.NoError(err)
+
+	fs := afero.NewMemMapFs()
+	a.NoError(afero.WriteFile(fs, "photos/DSC0001.jpg", []byte("this is a test"), 0644))
+	a.NoError(afero.WriteFile(fs, "photos/DSC0002.jpg", []byte("this is another test"), 0644))
+
+	entries, err := manifest.Open(afero.NewOsFs(), "testdata/manifest.csv")
+	a.NoError(err)
+
+	uploadables := manifest.NewUploadables(fs, entries, filesystem.NewAlbumResolver(mg, ""),
+		filesystem.Keywords("manifest"))
+	summary, err := smugmug.Summarize(mg.Upload.Uploads(context.TODO(), uploadables))
+	a.NoError(err)
+	a.Len(summary.Succeeded, 2)
+
+	value, ok := headers.Load("DSC0001.jpg")
+	a.True(ok)
+	header, _ := value.(http.Header)
+	a.Equal("/api/v2/album/7dFHSm", header.Get("X-Smug-AlbumUri"))
+	a.Equal(url.PathEscape("Marmot"), header.Get("X-Smug-Title"))
+	a.Equal(url.PathEscape("marmot; hiking; manifest"), header.Get("X-Smug-Keywords"))
+
+	value, ok = headers.Load("DSC0002.jpg")
+	a.True(ok)
+	header, _ = value.(http.Header)
+	a.Equal("/api/v2/album/a2", header.Get("X-Smug-AlbumUri"))
+	a.Equal("/api/v2/image/CVvj69L-0", header.Get("X-Smug-ImageUri"))
+}
+
+func TestUploadablesValidate(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	fs := afero.NewMemMapFs()
+	a.NoError(afero.WriteFile(fs, "photos/DSC0001.jpg", []byte("this is a test"), 0644))
+	a.NoError(fs.MkdirAll("photos/2024", 0755))
+
+	entries := []*manifest.Entry{
+		{Line: 2, Path: "photos/DSC0001.jpg", AlbumKey: "7dFHSm"},
+		{Line: 3, Path: "photos/missing.jpg", AlbumKey: "7dFHSm"},
+		{Line: 4, Path: "photos/2024", AlbumKey: "7dFHSm"},
+		{Line: 5, Path: "photos/DSC0001.jpg", Album: "/Misc"},
+	}
+
+	// nothing is uploaded if any entry is invalid
+	uploadablesc, errc := manifest.NewUploadables(fs, entries, nil).Uploadables(context.TODO())
+	var ups []*smugmug.Uploadable
+	for up := range uploadablesc {
+		ups = append(ups, up)
+	}
+	a.Empty(ups)
+	err := <-errc
+	a.ErrorContains(err, "line 3: ")
+	a.ErrorContains(err, "line 4: `photos/2024` is not a file")
+	a.ErrorContains(err, "line 5: album paths require a resolver")
+	a.NotContains(err.Error(), "line 2")
+
+	// errors creating an Uploadable report the line of the entry
+	uploadablesc, errc = manifest.NewUploadables(fs, entries[:1], nil, func(*smugmug.Uploadable) error {
+		return errors.New("failed")
+	}).Uploadables(context.TODO())
+	for range uploadablesc {
+		a.Fail("unexpected uploadable")
+	}
+	var lerr *manifest.LineError
+	a.ErrorAs(<-errc, &lerr)
+	a.Equal(2, lerr.Line)
+
+	// skipped entries are not uploaded
+	uploadablesc, errc = manifest.NewUploadables(fs, entries[:1], nil, func(*smugmug.Uploadable) error {
+		return filesystem.ErrSkip
+	}).Uploadables(context.TODO())
+	for range uploadablesc {
+		a.Fail("unexpected uploadable")
+	}
+	a.NoError(<-errc)
+}
+
+func TestUploadablesValidateAlbums(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	var headers sync.Map
+	svr := server(t, &headers)
+	defer svr.Close()
+
+	mg, err := smugmug.NewClient(smugmug.WithBaseURL(svr.URL))
+	a.NoError(err)
+
+	fs := afero.NewMemMapFs()
+	a.NoError(afero.WriteFile(fs, "photos/DSC0001.jpg", []byte("this is a test"), 0644))
+
+	// album paths are resolved before any Uploadable is created
+	entries := []*manifest.Entry{
+		{Line: 2, Path: "photos/DSC0001.jpg", Album: "/Misc"},
+		{Line: 3, Path: "photos/DSC0001.jpg", Album: "/Misc/2024"},
+	}
+	uploadablesc, errc := manifest.NewUploadables(fs, entries, filesystem.NewAlbumResolver(mg, "")).
+		Uploadables(context.TODO())
+	for range uploadablesc {
+		a.Fail("unexpected uploadable")
+	}
+	err = <-errc
+	a.ErrorContains(err, "line 3: expected `Misc` to be a folder not a album")
+	a.NotContains(err.Error(), "line 2")
+
+	// missing albums are not created until uploaded
+	entries = []*manifest.Entry{{Line: 2, Path: "photos/DSC0001.jpg", Album: "/Photos/2024"}}
+	uploadablesc, errc = manifest.NewUploadables(fs, entries, filesystem.NewAlbumResolver(mg, "")).
+		Uploadables(context.TODO())
+	var ups []*smugmug.Uploadable
+	for up := range uploadablesc {
+		ups = append(ups, up)
+	}
+	a.NoError(<-errc)
+	a.Len(ups, 1)
+	a.Empty(ups[0].AlbumKey)
+	a.Equal("/Photos/2024", ups[0].AlbumPath)
+	a.NotNil(ups[0].CreateAlbum)
+}
@@ -0,0 +1,216 @@
+package pipeline
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"slices"
+
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/time/rate"
+
+	"github.com/bzimmer/smugmug"
+)
+
+// Func adapts a function to the Uploadables interface
+type Func func(ctx context.Context) (<-chan *smugmug.Uploadable, <-chan error)
+
+// Uploadables calls the function
+func (f Func) Uploadables(ctx context.Context) (<-chan *smugmug.Uploadable, <-chan error) {
+	return f(ctx)
+}
+
+// send sends an Uploadable downstream, returning an error if the context is done first
+type send func(ctx context.Context, up *smugmug.Uploadable) error
+
+// stage returns an Uploadables running `fn` in a goroutine which sends Uploadables downstream
+// The channels are closed when `fn` returns and its error, if any, is sent on the error channel
+func stage(fn func(ctx context.Context, send send) error) smugmug.Uploadables {
+	return Func(func(ctx context.Context) (<-chan *smugmug.Uploadable, <-chan error) {
+		errc := make(chan error, 1)
+		uploadablesc := make(chan *smugmug.Uploadable)
+		go func() {
+			defer close(errc)
+			defer close(uploadablesc)
+			if err := fn(ctx, func(ctx context.Context, up *smugmug.Uploadable) error {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case uploadablesc <- up:
+					return nil
+				}
+			}); err != nil {
+				errc <- err
+			}
+		}()
+		return uploadablesc, errc
+	})
+}
+
+// consume calls `fn` with each Uploadable of `src` and returns the error of `src` or `fn`
+// The upstream stages are cancelled when consume returns so no goroutines are left blocked
+func consume(ctx context.Context, src smugmug.Uploadables, fn func(*smugmug.Uploadable) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	uploadablesc, errc := src.Uploadables(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case up, ok := <-uploadablesc:
+			if !ok {
+				return <-errc
+			}
+			if err := fn(up); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Slice returns an Uploadables of the Uploadables in order
+func Slice(ups ...*smugmug.Uploadable) smugmug.Uploadables {
+	return stage(func(ctx context.Context, send send) error {
+		for _, up := range ups {
+			if err := send(ctx, up); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Filter passes the Uploadables for which `fn` returns true
+// Uploadables skipped upstream are passed without calling `fn`
+func Filter(
+	src smugmug.Uploadables, fn func(context.Context, *smugmug.Uploadable) (bool, error)) smugmug.Uploadables {
+	return stage(func(ctx context.Context, send send) error {
+		return consume(ctx, src, func(up *smugmug.Uploadable) error {
+			if up.Skipped != nil {
+				return send(ctx, up)
+			}
+			ok, err := fn(ctx, up)
+			if err != nil || !ok {
+				return err
+			}
+			return send(ctx, up)
+		})
+	})
+}
+
+// Map passes the Uploadable returned by `fn` for each Uploadable
+// If `fn` returns an error wrapping ErrSkip the Uploadable is passed with the error as its Skipped so
+// it is reported by `Uploads` without being uploaded
+// Uploadables skipped upstream are passed without calling `fn`
+func Map(
+	src smugmug.Uploadables,
+	fn func(context.Context, *smugmug.Uploadable) (*smugmug.Uploadable, error)) smugmug.Uploadables {
+	return stage(func(ctx context.Context, send send) error {
+		return consume(ctx, src, func(up *smugmug.Uploadable) error {
+			if up.Skipped != nil {
+				return send(ctx, up)
+			}
+			mapped, err := fn(ctx, up)
+			if err != nil {
+				if !errors.Is(err, smugmug.ErrSkip) {
+					return err
+				}
+				up.Skipped = err
+				return send(ctx, up)
+			}
+			return send(ctx, mapped)
+		})
+	})
+}
+
+// Tee calls `sink` with each Uploadable before passing it downstream, eg to record a manifest or to
+// feed a second consumer
+// The Uploadable is shared so `sink` must not modify it
+func Tee(src smugmug.Uploadables, sink func(context.Context, *smugmug.Uploadable) error) smugmug.Uploadables {
+	return stage(func(ctx context.Context, send send) error {
+		return consume(ctx, src, func(up *smugmug.Uploadable) error {
+			if err := sink(ctx, up); err != nil {
+				return err
+			}
+			return send(ctx, up)
+		})
+	})
+}
+
+// Merge passes the Uploadables of all sources as they become available
+// The first error of any source stops all sources
+func Merge(srcs ...smugmug.Uploadables) smugmug.Uploadables {
+	return stage(func(ctx context.Context, send send) error {
+		grp, ctx := errgroup.WithContext(ctx)
+		for _, src := range srcs {
+			grp.Go(func() error {
+				return consume(ctx, src, func(up *smugmug.Uploadable) error {
+					return send(ctx, up)
+				})
+			})
+		}
+		return grp.Wait()
+	})
+}
+
+// Buffer reads up to `size` Uploadables ahead of the downstream stage, passing them no faster than
+// `limiter` allows; a nil limiter does not limit the rate
+func Buffer(src smugmug.Uploadables, size int, limiter *rate.Limiter) smugmug.Uploadables {
+	return stage(func(ctx context.Context, send send) error {
+		grp, ctx := errgroup.WithContext(ctx)
+		bufferc := make(chan *smugmug.Uploadable, max(size, 0))
+		grp.Go(func() error {
+			defer close(bufferc)
+			return consume(ctx, src, func(up *smugmug.Uploadable) error {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case bufferc <- up:
+					return nil
+				}
+			})
+		})
+		grp.Go(func() error {
+			for up := range bufferc {
+				if limiter != nil {
+					if err := limiter.Wait(ctx); err != nil {
+						return err
+					}
+				}
+				if err := send(ctx, up); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		return grp.Wait()
+	})
+}
+
+// Sort reads all Uploadables of `src` and passes them ordered by `cmp`, keeping the order of equal Uploadables
+// Nothing is passed if `src` fails
+func Sort(src smugmug.Uploadables, cmp func(a, b *smugmug.Uploadable) int) smugmug.Uploadables {
+	return stage(func(ctx context.Context, send send) error {
+		var ups []*smugmug.Uploadable
+		if err := consume(ctx, src, func(up *smugmug.Uploadable) error {
+			ups = append(ups, up)
+			return nil
+		}); err != nil {
+			return err
+		}
+		slices.SortStableFunc(ups, cmp)
+		for _, up := range ups {
+			if err := send(ctx, up); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// SortBySize passes the Uploadables of `src` smallest first
+func SortBySize(src smugmug.Uploadables) smugmug.Uploadables {
+	return Sort(src, func(a, b *smugmug.Uploadable) int {
+		return cmp.Compare(a.Size, b.Size)
+	})
+}
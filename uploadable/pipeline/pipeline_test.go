@@ -0,0 +1,227 @@
+package pipeline_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/time/rate"
+
+	"github.com/bzimmer/smugmug"
+	"github.com/bzimmer/smugmug/uploadable/pipeline"
+)
+
+var errFailed = errors.New("failed")
+
+func uploadables(sizes ...int64) []*smugmug.Uploadable {
+	ups := make([]*smugmug.Uploadable, len(sizes))
+	for i, size := range sizes {
+		ups[i] = &smugmug.Uploadable{Name: fmt.Sprintf("DSC%04d.jpg", i), Size: size}
+	}
+	return ups
+}
+
+// failing returns the Uploadables followed by an error
+func failing(ups ...*smugmug.Uploadable) smugmug.Uploadables {
+	return pipeline.Func(func(ctx context.Context) (<-chan *smugmug.Uploadable, <-chan error) {
+		errc := make(chan error, 1)
+		uploadablesc, upstreamc := pipeline.Slice(ups...).Uploadables(ctx)
+		out := make(chan *smugmug.Uploadable)
+		go func() {
+			defer close(errc)
+			defer close(out)
+			for up := range uploadablesc {
+				select {
+				case <-ctx.Done():
+					errc <- ctx.Err()
+					return
+				case out <- up:
+				}
+			}
+			if err := <-upstreamc; err != nil {
+				errc <- err
+				return
+			}
+			errc <- errFailed
+		}()
+		return out, errc
+	})
+}
+
+func names(ups []*smugmug.Uploadable) []string {
+	s := make([]string, len(ups))
+	for i, up := range ups {
+		s[i] = up.Name
+	}
+	return s
+}
+
+func collect(ctx context.Context, src smugmug.Uploadables) ([]*smugmug.Uploadable, error) {
+	uploadablesc, errc := src.Uploadables(ctx)
+	var ups []*smugmug.Uploadable
+	for up := range uploadablesc {
+		ups = append(ups, up)
+	}
+	return ups, <-errc
+}
+
+func TestFilterMap(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	src := pipeline.Slice(uploadables(1, 2, 3, 4, 5)...)
+	src = pipeline.Filter(src, func(_ context.Context, up *smugmug.Uploadable) (bool, error) {
+		return up.Size%2 == 1, nil
+	})
+	src = pipeline.Map(src, func(_ context.Context, up *smugmug.Uploadable) (*smugmug.Uploadable, error) {
+		if up.Size == 3 {
+			return nil, fmt.Errorf("%w: three", smugmug.ErrSkip)
+		}
+		return &smugmug.Uploadable{Name: up.Name, Size: up.Size * 10}, nil
+	})
+	ups, err := collect(context.TODO(), src)
+	a.NoError(err)
+	a.Equal([]string{"DSC0000.jpg", "DSC0002.jpg", "DSC0004.jpg"}, names(ups))
+	a.Equal(int64(50), ups[2].Size)
+	// an Uploadable skipped by `fn` is passed for reporting
+	a.NoError(ups[0].Skipped)
+	a.ErrorIs(ups[1].Skipped, smugmug.ErrSkip)
+	a.ErrorContains(ups[1].Skipped, "three")
+	a.Equal(int64(3), ups[1].Size)
+
+	ups, err = collect(context.TODO(), pipeline.Filter(pipeline.Slice(uploadables(1, 2)...),
+		func(context.Context, *smugmug.Uploadable) (bool, error) {
+			return false, errFailed
+		}))
+	a.ErrorIs(err, errFailed)
+	a.Empty(ups)
+
+	ups, err = collect(context.TODO(), pipeline.Map(pipeline.Slice(uploadables(1, 2)...),
+		func(context.Context, *smugmug.Uploadable) (*smugmug.Uploadable, error) {
+			return nil, errFailed
+		}))
+	a.ErrorIs(err, errFailed)
+	a.Empty(ups)
+}
+
+func TestFilterMapSkipped(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	// Uploadables skipped upstream are passed through for reporting
+	skipped := &smugmug.Uploadable{Name: "DSC0009.jpg", Skipped: smugmug.ErrSkip}
+	src := pipeline.Filter(pipeline.Slice(skipped), func(context.Context, *smugmug.Uploadable) (bool, error) {
+		return false, nil
+	})
+	src = pipeline.Map(src, func(context.Context, *smugmug.Uploadable) (*smugmug.Uploadable, error) {
+		return nil, errFailed
+	})
+	ups, err := collect(context.TODO(), src)
+	a.NoError(err)
+	a.Equal([]*smugmug.Uploadable{skipped}, ups)
+}
+
+func TestTee(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	var seen []string
+	ups, err := collect(context.TODO(), pipeline.Tee(pipeline.Slice(uploadables(1, 2, 3)...),
+		func(_ context.Context, up *smugmug.Uploadable) error {
+			seen = append(seen, up.Name)
+			return nil
+		}))
+	a.NoError(err)
+	a.Equal(seen, names(ups))
+	a.Len(ups, 3)
+
+	ups, err = collect(context.TODO(), pipeline.Tee(pipeline.Slice(uploadables(1, 2, 3)...),
+		func(context.Context, *smugmug.Uploadable) error {
+			return errFailed
+		}))
+	a.ErrorIs(err, errFailed)
+	a.Empty(ups)
+}
+
+func TestMerge(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	first, second := uploadables(1, 2, 3), uploadables(4, 5)
+	ups, err := collect(context.TODO(), pipeline.Merge(pipeline.Slice(first...), pipeline.Slice(second...)))
+	a.NoError(err)
+	a.ElementsMatch(append(first, second...), ups)
+
+	ups, err = collect(context.TODO(), pipeline.Merge())
+	a.NoError(err)
+	a.Empty(ups)
+
+	_, err = collect(context.TODO(), pipeline.Merge(pipeline.Slice(first...), failing(second...)))
+	a.ErrorIs(err, errFailed)
+}
+
+func TestBuffer(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	ups, err := collect(context.TODO(), pipeline.Buffer(pipeline.Slice(uploadables(1, 2, 3)...), 2, nil))
+	a.NoError(err)
+	a.Equal([]string{"DSC0000.jpg", "DSC0001.jpg", "DSC0002.jpg"}, names(ups))
+
+	// the first Uploadable uses the burst and the remaining are spaced by the limit
+	t0 := time.Now()
+	ups, err = collect(context.TODO(), pipeline.Buffer(
+		pipeline.Slice(uploadables(1, 2, 3)...), 0, rate.NewLimiter(rate.Every(50*time.Millisecond), 1)))
+	a.NoError(err)
+	a.Len(ups, 3)
+	a.GreaterOrEqual(time.Since(t0), 90*time.Millisecond)
+
+	ups, err = collect(context.TODO(), pipeline.Buffer(failing(uploadables(1, 2, 3)...), 5, nil))
+	a.ErrorIs(err, errFailed)
+	a.LessOrEqual(len(ups), 3)
+}
+
+func TestSortBySize(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	ups, err := collect(context.TODO(), pipeline.SortBySize(pipeline.Slice(uploadables(3, 1, 2, 1)...)))
+	a.NoError(err)
+	a.Equal([]string{"DSC0001.jpg", "DSC0003.jpg", "DSC0002.jpg", "DSC0000.jpg"}, names(ups))
+
+	ups, err = collect(context.TODO(), pipeline.SortBySize(failing(uploadables(3, 1, 2)...)))
+	a.ErrorIs(err, errFailed)
+	a.Empty(ups)
+}
+
+func TestCancel(t *testing.T) {
+	a := assert.New(t)
+
+	goroutines := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	src := pipeline.Slice(uploadables(slices.Repeat([]int64{1}, 100)...)...)
+	src = pipeline.Merge(src, pipeline.Slice(uploadables(2, 3)...))
+	src = pipeline.Buffer(src, 10, nil)
+	src = pipeline.Tee(src, func(context.Context, *smugmug.Uploadable) error { return nil })
+	src = pipeline.Filter(src, func(context.Context, *smugmug.Uploadable) (bool, error) { return true, nil })
+
+	uploadablesc, errc := src.Uploadables(ctx)
+	<-uploadablesc
+	cancel()
+	for range uploadablesc { //nolint:revive // drain
+	}
+	a.ErrorIs(<-errc, context.Canceled)
+
+	// all stages exit once the consumer is cancelled
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > goroutines && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	a.LessOrEqual(runtime.NumGoroutine(), goroutines)
+}
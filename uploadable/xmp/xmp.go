@@ -0,0 +1,229 @@
+package xmp
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	nsRDF       = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+	nsXML       = "http://www.w3.org/XML/1998/namespace"
+	nsDC        = "http://purl.org/dc/elements/1.1/"
+	nsXMP       = "http://ns.adobe.com/xap/1.0/"
+	nsEXIF      = "http://ns.adobe.com/exif/1.0/"
+	nsPhotoshop = "http://ns.adobe.com/photoshop/1.0/"
+
+	// defaultLang is the language of the preferred value of a language alternative
+	defaultLang = "x-default"
+)
+
+// ErrMalformed indicates the XMP packet could not be parsed
+var ErrMalformed = errors.New("xmp: malformed metadata")
+
+// GPS is the location at which a photo was taken
+type GPS struct {
+	// Latitude in decimal degrees
+	Latitude float64
+	// Longitude in decimal degrees
+	Longitude float64
+	// Altitude in meters
+	Altitude float64
+}
+
+// XMP is the subset of XMP (including the IPTC Core properties) metadata applied to uploads
+type XMP struct {
+	// Title is the dc:title, or the photoshop:Headline if no title was recorded
+	Title string
+	// Description is the dc:description
+	Description string
+	// Keywords are the dc:subject
+	Keywords []string
+	// Rating is the xmp:Rating, zero if not rated and -1 if rejected
+	Rating int
+	// GPS is the location recorded in the exif:GPS properties, nil if not recorded
+	GPS *GPS
+}
+
+// Decode reads the metadata from an XMP packet such as a sidecar file
+func Decode(r io.Reader) (*XMP, error) {
+	var (
+		stack []xml.StartElement
+		props = make(map[xml.Name]string)
+		lists = make(map[xml.Name][]string)
+	)
+	dec := xml.NewDecoder(r)
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrMalformed, err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if t.Name.Space == nsRDF && t.Name.Local == "Description" {
+				// simple properties may be written as attributes of the description
+				for _, attr := range t.Attr {
+					props[attr.Name] = strings.TrimSpace(attr.Value)
+				}
+			}
+			stack = append(stack, t)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			text := strings.TrimSpace(string(t))
+			if text == "" || len(stack) == 0 {
+				continue
+			}
+			top := stack[len(stack)-1]
+			if top.Name.Space != nsRDF || top.Name.Local != "li" {
+				props[top.Name] = text
+				continue
+			}
+			prop, ok := property(stack)
+			if !ok {
+				continue
+			}
+			if lang(top) == defaultLang {
+				lists[prop] = append([]string{text}, lists[prop]...)
+			} else {
+				lists[prop] = append(lists[prop], text)
+			}
+		}
+	}
+	return newXMP(props, lists)
+}
+
+// property returns the name of the nearest enclosing element which is not part of the RDF syntax
+func property(stack []xml.StartElement) (xml.Name, bool) {
+	for i := len(stack) - 1; i >= 0; i-- {
+		if stack[i].Name.Space != nsRDF {
+			return stack[i].Name, true
+		}
+	}
+	return xml.Name{}, false
+}
+
+// lang returns the xml:lang of the element
+func lang(e xml.StartElement) string {
+	for _, attr := range e.Attr {
+		if attr.Name.Space == nsXML && attr.Name.Local == "lang" {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+func newXMP(props map[xml.Name]string, lists map[xml.Name][]string) (*XMP, error) {
+	x := &XMP{
+		Title:       first(lists[xml.Name{Space: nsDC, Local: "title"}]),
+		Description: first(lists[xml.Name{Space: nsDC, Local: "description"}]),
+		Keywords:    lists[xml.Name{Space: nsDC, Local: "subject"}],
+	}
+	if x.Title == "" {
+		x.Title = props[xml.Name{Space: nsPhotoshop, Local: "Headline"}]
+	}
+	if rating, ok := props[xml.Name{Space: nsXMP, Local: "Rating"}]; ok {
+		// ratings are integers but some applications write them as reals
+		f, err := strconv.ParseFloat(rating, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid rating `%s`", ErrMalformed, rating)
+		}
+		x.Rating = int(f)
+	}
+	gps, err := newGPS(props)
+	if err != nil {
+		return nil, err
+	}
+	x.GPS = gps
+	return x, nil
+}
+
+func newGPS(props map[xml.Name]string) (*GPS, error) {
+	lat, ok := props[xml.Name{Space: nsEXIF, Local: "GPSLatitude"}]
+	if !ok {
+		return nil, nil //nolint:nilnil // no location is not an error
+	}
+	lng, ok := props[xml.Name{Space: nsEXIF, Local: "GPSLongitude"}]
+	if !ok {
+		return nil, fmt.Errorf("%w: missing longitude", ErrMalformed)
+	}
+	var (
+		gps GPS
+		err error
+	)
+	if gps.Latitude, err = coordinate(lat, 'N', 'S'); err != nil {
+		return nil, err
+	}
+	if gps.Longitude, err = coordinate(lng, 'E', 'W'); err != nil {
+		return nil, err
+	}
+	if alt, ok := props[xml.Name{Space: nsEXIF, Local: "GPSAltitude"}]; ok {
+		if gps.Altitude, err = rational(alt); err != nil {
+			return nil, err
+		}
+		if props[xml.Name{Space: nsEXIF, Local: "GPSAltitudeRef"}] == "1" {
+			gps.Altitude = -gps.Altitude
+		}
+	}
+	return &gps, nil
+}
+
+// coordinate parses a GPSCoordinate of the form `DDD,MM,SSk` or `DDD,MM.mmk` into decimal degrees
+func coordinate(s string, positive, negative byte) (float64, error) {
+	if len(s) < 2 { //nolint:mnd // at least a digit and a direction
+		return 0, fmt.Errorf("%w: invalid coordinate `%s`", ErrMalformed, s)
+	}
+	direction := strings.ToUpper(s[len(s)-1:])[0]
+	if direction != positive && direction != negative {
+		return 0, fmt.Errorf("%w: invalid coordinate `%s`", ErrMalformed, s)
+	}
+	var degrees float64
+	for i, part := range strings.Split(s[:len(s)-1], ",") {
+		if i > 2 { //nolint:mnd // degrees, minutes and seconds
+			return 0, fmt.Errorf("%w: invalid coordinate `%s`", ErrMalformed, s)
+		}
+		f, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%w: invalid coordinate `%s`", ErrMalformed, s)
+		}
+		for range i {
+			f /= 60
+		}
+		degrees += f
+	}
+	if direction == negative {
+		degrees = -degrees
+	}
+	return degrees, nil
+}
+
+// rational parses a value of the form `n/d` or a decimal
+func rational(s string) (float64, error) {
+	n, d, ok := strings.Cut(s, "/")
+	num, err := strconv.ParseFloat(n, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid rational `%s`", ErrMalformed, s)
+	}
+	if !ok {
+		return num, nil
+	}
+	den, err := strconv.ParseFloat(d, 64)
+	if err != nil || den == 0 {
+		return 0, fmt.Errorf("%w: invalid rational `%s`", ErrMalformed, s)
+	}
+	return num / den, nil
+}
+
+// first returns the first value or the empty string
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
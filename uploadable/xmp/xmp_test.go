@@ -0,0 +1,100 @@
+package xmp_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bzimmer/smugmug/uploadable/xmp"
+)
+
+func TestDecode(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		filename string
+		xmp      *xmp.XMP
+	}{
+		{
+			name:     "lightroom",
+			filename: "testdata/lightroom.xmp",
+			xmp: &xmp.XMP{
+				Title:       "Marmot",
+				Description: "A marmot, sunning on a rock",
+				Keywords:    []string{"marmot", "hiking"},
+				Rating:      4,
+				GPS:         &xmp.GPS{Latitude: 47 + 36.38/60, Longitude: -(122 + 19.9/60), Altitude: 52.5},
+			},
+		},
+		{
+			name:     "darktable",
+			filename: "testdata/darktable.xmp",
+			xmp: &xmp.XMP{
+				Title:  "Summit",
+				Rating: -1,
+				GPS:    &xmp.GPS{Latitude: -(33 + 51.0/60 + 24.6/3600), Longitude: 151 + 12.0/60 + 36.0/3600, Altitude: -12},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			fp, err := os.Open(tt.filename)
+			a.NoError(err)
+			defer fp.Close()
+
+			x, err := xmp.Decode(fp)
+			a.NoError(err)
+			a.NotNil(x)
+			a.Equal(tt.xmp.Title, x.Title)
+			a.Equal(tt.xmp.Description, x.Description)
+			a.Equal(tt.xmp.Keywords, x.Keywords)
+			a.Equal(tt.xmp.Rating, x.Rating)
+			a.InDelta(tt.xmp.GPS.Latitude, x.GPS.Latitude, 1e-9)
+			a.InDelta(tt.xmp.GPS.Longitude, x.GPS.Longitude, 1e-9)
+			a.InDelta(tt.xmp.GPS.Altitude, x.GPS.Altitude, 1e-9)
+		})
+	}
+}
+
+func TestDecodeEmpty(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	x, err := xmp.Decode(strings.NewReader(`<x:xmpmeta xmlns:x="adobe:ns:meta/"/>`))
+	a.NoError(err)
+	a.Equal(&xmp.XMP{}, x)
+}
+
+func TestDecodeMalformed(t *testing.T) {
+	t.Parallel()
+
+	const description = `<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">` +
+		`<rdf:Description xmlns:xmp="http://ns.adobe.com/xap/1.0/" xmlns:exif="http://ns.adobe.com/exif/1.0/" %s/>` +
+		`</rdf:RDF>`
+
+	for name, attrs := range map[string]string{
+		"truncated":         "<rdf:RDF",
+		"rating":            strings.Replace(description, "%s", `xmp:Rating="five"`, 1),
+		"missing longitude": strings.Replace(description, "%s", `exif:GPSLatitude="47,36N"`, 1),
+		"direction":         strings.Replace(description, "%s", `exif:GPSLatitude="47,36E" exif:GPSLongitude="1,2W"`, 1),
+		"coordinate":        strings.Replace(description, "%s", `exif:GPSLatitude="47,3x6N" exif:GPSLongitude="1,2W"`, 1),
+		"components":        strings.Replace(description, "%s", `exif:GPSLatitude="1,2,3,4N" exif:GPSLongitude="1,2W"`, 1),
+		"altitude": strings.Replace(description, "%s",
+			`exif:GPSLatitude="47,36N" exif:GPSLongitude="1,2W" exif:GPSAltitude="1/0"`, 1),
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			x, err := xmp.Decode(strings.NewReader(attrs))
+			a.ErrorIs(err, xmp.ErrMalformed)
+			a.Nil(x)
+		})
+	}
+}
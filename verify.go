@@ -0,0 +1,93 @@
+package smugmug
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+// VerificationError reports an uploaded image which could not be confirmed to match its Uploadable
+type VerificationError struct {
+	// Upload is the completed upload
+	Upload *Upload
+	// Image is the uploaded image as reported by SmugMug, nil if processing did not finish
+	Image *Image
+	// Reason describes the mismatch
+	Reason string
+}
+
+func (e *VerificationError) Error() string {
+	name := ""
+	if e.Upload != nil && e.Upload.Uploadable != nil {
+		name = e.Upload.Uploadable.Name
+	}
+	return fmt.Sprintf("failed to verify upload of `%s`: %s", name, e.Reason)
+}
+
+// WithVerification configures uploads to wait for SmugMug to finish processing each image and then
+// verify the archived MD5 and size match the Uploadable
+// The image is polled every `interval` for at most `timeout`; a timeout of zero waits until the context is done
+func WithVerification(interval, timeout time.Duration) Option {
+	return func(c *Client) error {
+		if interval <= 0 {
+			return errors.New("verification interval must be positive")
+		}
+		if timeout < 0 {
+			return errors.New("verification timeout must not be negative")
+		}
+		c.verifyInterval = interval
+		c.verifyTimeout = timeout
+		return nil
+	}
+}
+
+// verify waits for the uploaded image to finish processing and compares it to the Uploadable
+func (s *UploadService) verify(ctx context.Context, upload *Upload) error {
+	if upload.ImageURI == "" {
+		return &VerificationError{Upload: upload, Reason: "missing image uri"}
+	}
+	parent := ctx
+	if s.client.verifyTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.client.verifyTimeout)
+		defer cancel()
+	}
+
+	imageKey := path.Base(upload.ImageURI)
+	for {
+		image, err := s.client.Image.Image(ctx, imageKey)
+		if err != nil {
+			if parent.Err() == nil && ctx.Err() != nil {
+				return &VerificationError{Upload: upload, Reason: "timed out waiting for processing"}
+			}
+			return err
+		}
+		if !image.Processing {
+			return verified(upload, image)
+		}
+		select {
+		case <-parent.Done():
+			return parent.Err()
+		case <-ctx.Done():
+			return &VerificationError{Upload: upload, Reason: "timed out waiting for processing"}
+		case <-time.After(s.client.verifyInterval):
+		}
+	}
+}
+
+// verified compares the archived MD5 and size of the image to the Uploadable
+func verified(upload *Upload, image *Image) error {
+	up := upload.Uploadable
+	if up.MD5 != "" && !strings.EqualFold(up.MD5, image.ArchivedMD5) {
+		return &VerificationError{Upload: upload, Image: image,
+			Reason: fmt.Sprintf("archived md5 {%s} does not match {%s}", image.ArchivedMD5, up.MD5)}
+	}
+	if int64(image.ArchivedSize) != up.Size {
+		return &VerificationError{Upload: upload, Image: image,
+			Reason: fmt.Sprintf("archived size {%d} does not match {%d}", image.ArchivedSize, up.Size)}
+	}
+	return nil
+}
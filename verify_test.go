@@ -0,0 +1,165 @@
+package smugmug_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bzimmer/smugmug"
+)
+
+func TestWithVerification(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	mg, err := smugmug.NewClient(smugmug.WithVerification(0, time.Second))
+	a.Error(err)
+	a.Nil(mg)
+
+	mg, err = smugmug.NewClient(smugmug.WithVerification(time.Millisecond, -time.Second))
+	a.Error(err)
+	a.Nil(mg)
+}
+
+func TestUploadVerification(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		md5        string
+		size       int64
+		processing int32
+		timeout    time.Duration
+		polls      int32
+		reason     string
+	}{
+		{
+			name:  "verified",
+			md5:   "54b0c58c7ce9f2a8b551351102ee0938",
+			size:  14,
+			polls: 1,
+		},
+		{
+			name:       "verified after processing",
+			md5:        "54B0C58C7CE9F2A8B551351102EE0938",
+			size:       14,
+			processing: 2,
+			polls:      3,
+		},
+		{
+			name:   "md5 mismatch",
+			md5:    "3004be323291d2512c55bbc4c5536194",
+			size:   14,
+			polls:  1,
+			reason: "archived md5",
+		},
+		{
+			name:   "size mismatch",
+			size:   15,
+			polls:  1,
+			reason: "archived size",
+		},
+		{
+			name:       "processing timeout",
+			md5:        "54b0c58c7ce9f2a8b551351102ee0938",
+			size:       14,
+			processing: 1000,
+			timeout:    50 * time.Millisecond,
+			reason:     "timed out",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			var polls atomic.Int32
+			mux := http.NewServeMux()
+			mux.HandleFunc("/{$}", func(w http.ResponseWriter, r *http.Request) {
+				http.ServeFile(w, r, "testdata/upload_CVvj69L.json")
+			})
+			mux.HandleFunc("/image/CVvj69L-0", func(w http.ResponseWriter, r *http.Request) {
+				if polls.Add(1) <= tt.processing {
+					http.ServeFile(w, r, "testdata/image_CVvj69L-0_processing.json")
+					return
+				}
+				http.ServeFile(w, r, "testdata/image_CVvj69L-0.json")
+			})
+			svr := httptest.NewServer(mux)
+			defer svr.Close()
+
+			mg, err := smugmug.NewClient(
+				smugmug.WithBaseURL(svr.URL),
+				smugmug.WithUploadURL(svr.URL),
+				smugmug.WithVerification(time.Millisecond, tt.timeout))
+			a.NoError(err)
+
+			up := &smugmug.Uploadable{Name: "DSC0001.jpg", AlbumKey: "7dFHSm", MD5: tt.md5, Size: tt.size,
+				Reader: strings.NewReader(strings.Repeat("a", int(tt.size)))}
+			upload, err := mg.Upload.Upload(context.TODO(), up)
+			if tt.polls > 0 {
+				a.Equal(tt.polls, polls.Load())
+			}
+			if tt.reason == "" {
+				a.NoError(err)
+				a.NotNil(upload)
+				return
+			}
+			a.Nil(upload)
+			var verr *smugmug.VerificationError
+			a.True(errors.As(err, &verr))
+			a.Contains(verr.Reason, tt.reason)
+			a.Equal("/api/v2/image/CVvj69L-0", verr.Upload.ImageURI)
+			a.Contains(err.Error(), "DSC0001.jpg")
+		})
+	}
+}
+
+func TestUploadsVerificationInFlight(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	// the images finish processing only once both files are uploaded so an upload waiting for processing
+	// must not hold its in-flight bytes
+	var puts atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", func(w http.ResponseWriter, r *http.Request) {
+		puts.Add(1)
+		http.ServeFile(w, r, "testdata/upload_CVvj69L.json")
+	})
+	mux.HandleFunc("/image/CVvj69L-0", func(w http.ResponseWriter, r *http.Request) {
+		if puts.Load() < 2 {
+			http.ServeFile(w, r, "testdata/image_CVvj69L-0_processing.json")
+			return
+		}
+		http.ServeFile(w, r, "testdata/image_CVvj69L-0.json")
+	})
+	svr := httptest.NewServer(mux)
+	defer svr.Close()
+
+	mg, err := smugmug.NewClient(
+		smugmug.WithBaseURL(svr.URL),
+		smugmug.WithUploadURL(svr.URL),
+		smugmug.WithConcurrency(2),
+		smugmug.WithMaxInFlight(14),
+		smugmug.WithVerification(time.Millisecond, 2*time.Second))
+	a.NoError(err)
+
+	var ups sliceUploadables
+	for _, name := range []string{"DSC0001.jpg", "DSC0002.jpg"} {
+		ups = append(ups, &smugmug.Uploadable{Name: name, AlbumKey: "7dFHSm",
+			MD5: "54b0c58c7ce9f2a8b551351102ee0938", Size: 14, Reader: strings.NewReader(strings.Repeat("a", 14))})
+	}
+	summary, err := smugmug.Summarize(mg.Upload.Uploads(context.TODO(), ups))
+	a.NoError(err)
+	a.Len(summary.Succeeded, 2)
+	a.Equal(int32(2), puts.Load())
+}